@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// audioMix is the processing applied to the clip audio on export, on top
+// of joining the clips: per-clip loudness normalization and the music bed.
+type audioMix struct {
+	music        *musicMix
+	clipLoudness []string // loudnorm filter per clip, "" to leave a clip as is
+	sampleRate   int
+
+	// intermediate is set when the export is a temporary file that gets
+	// its loudness normalized afterwards. Its audio is left uncompressed.
+	intermediate bool
+}
+
+// filtersAudio reports whether the joined clip audio has to go through the
+// filter graph, which rules out copying it.
+func (m *audioMix) filtersAudio() bool {
+	return m.music != nil || m.normalizesClips()
+}
+
+// normalizesClips reports whether any clip has its loudness normalized.
+func (m *audioMix) normalizesClips() bool {
+	return slices.ContainsFunc(m.clipLoudness, func(filter string) bool { return filter != "" })
+}
+
+// inputArgs returns the inputs added after the clips.
+func (m *audioMix) inputArgs() []string {
+	if m.music == nil {
+		return nil
+	}
+	return m.music.inputArgs()
+}
+
+// inputCount returns the number of inputs added by inputArgs.
+func (m *audioMix) inputCount() int {
+	if m.music == nil {
+		return 0
+	}
+	return len(m.music.bed.Tracks)
+}
+
+// codecArgs returns the audio codec options for the output.
+func (m *audioMix) codecArgs() []string {
+	if m.intermediate {
+		return []string{"-c:a", "pcm_s24le"}
+	}
+	return nil
+}
+
+// normalizeClips routes the audio of each clip through its loudness
+// filter before the clips are joined.
+func (m *audioMix) normalizeClips(inputs *streamInputs) {
+	for i, filter := range m.clipLoudness {
+		if filter == "" {
+			continue
+		}
+		inputs.filters = append(inputs.filters, fmt.Sprintf("%s%s[ln%d]", inputs.audio[i], filter, i))
+		inputs.audio[i] = fmt.Sprintf("[ln%d]", i)
+	}
+}
+
+// splitClips normalizes the audio of clips that were joined by the concat
+// demuxer, cutting program back into the clips by their lengths. The
+// result is [aclips].
+func (m *audioMix) splitClips(videos []*Video, program string) []string {
+	n := len(videos)
+	var outputs string
+	for i := range videos {
+		outputs += fmt.Sprintf("[cs%d]", i)
+	}
+	chains := []string{fmt.Sprintf("%sasplit=%d%s", program, n, outputs)}
+
+	var start float64
+	var joined string
+	for i, video := range videos {
+		end := start + video.TrimmedDuration().Seconds()
+		chain := fmt.Sprintf("[cs%d]atrim=start=%.3f:end=%.3f,asetpts=PTS-STARTPTS", i, start, end)
+		if m.clipLoudness[i] != "" {
+			chain += "," + m.clipLoudness[i]
+		}
+		chains = append(chains, fmt.Sprintf("%s[cl%d]", chain, i))
+		joined += fmt.Sprintf("[cl%d]", i)
+		start = end
+	}
+
+	return append(chains, fmt.Sprintf("%sconcat=n=%d:v=0:a=1[aclips]", joined, n))
+}
+
+// filters mixes the music under the program audio, with its inputs
+// starting at firstInput. It returns the chains and the label of the
+// final audio.
+func (m *audioMix) filters(program string, firstInput int) ([]string, string) {
+	if m.music == nil {
+		return nil, program
+	}
+	return m.music.filters(program, firstInput), "[aout]"
+}
+
+// filterArgs returns the -filter_complex and -map options for a graph that
+// ends in [vout] and [aclips], with the audio mix applied and any burned-in
+// subtitles drawn. Music inputs start at firstMusicInput.
+func filterArgs(chains []string, mix *audioMix, extras *exportExtras, firstMusicInput int) []string {
+	video := "[vout]"
+	if extras.burn != "" {
+		chains = append(chains, "[vout]subtitles=filename="+filterQuote(filepath.ToSlash(extras.burn))+"[vsubs]")
+		video = "[vsubs]"
+	}
+
+	audioChains, audio := mix.filters("[aclips]", firstMusicInput)
+	chains = append(chains, audioChains...)
+	return []string{"-filter_complex", strings.Join(chains, ";"), "-map", video, "-map", audio}
+}
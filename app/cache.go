@@ -0,0 +1,285 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mediaCacheVersion is part of every cache key. Bump it when MediaInfo
+// gains fields, so files probed by an older version are probed again.
+const mediaCacheVersion = 2
+
+// defaultCacheSize caps the on-disk cache; the least recently used entries
+// are evicted beyond it.
+const defaultCacheSize = 512 * 1024 * 1024
+
+// MediaCache stores probed metadata and thumbnails on disk so reopening a
+// project doesn't run ffprobe and ffmpeg again. Entries are keyed by path,
+// size and modification time, so an edited file is probed afresh.
+type MediaCache struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+	size    int64 // total size of the files in dir, kept up to date by store
+}
+
+type cacheEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Media   MediaInfo `json:"media"`
+}
+
+var (
+	mediaCacheOnce sync.Once
+	mediaCache     *MediaCache
+)
+
+// DefaultMediaCache returns the cache under the user cache directory, or
+// nil if there is none. A nil cache is valid and never hits.
+func DefaultMediaCache() *MediaCache {
+	mediaCacheOnce.Do(func() {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			log.Printf("Media cache disabled: %v", err)
+			return
+		}
+		cache, err := NewMediaCache(filepath.Join(base, "video-arranger", "media"), defaultCacheSize)
+		if err != nil {
+			log.Printf("Media cache disabled: %v", err)
+			return
+		}
+		mediaCache = cache
+	})
+	return mediaCache
+}
+
+func NewMediaCache(dir string, maxSize int64) (*MediaCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &MediaCache{dir: dir, maxSize: maxSize}
+	c.size = c.diskUsage()
+	return c, nil
+}
+
+func cacheKey(path string, size int64, modTime time.Time) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%d\x00%d", mediaCacheVersion, path, size, modTime.UnixNano())))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *MediaCache) entryPath(key, ext string) string {
+	return filepath.Join(c.dir, key+ext)
+}
+
+func (c *MediaCache) LoadMedia(path string, size int64, modTime time.Time) (MediaInfo, bool) {
+	if c == nil {
+		return MediaInfo{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file := c.entryPath(cacheKey(path, size, modTime), ".json")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return MediaInfo{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Path != path {
+		return MediaInfo{}, false
+	}
+
+	c.touch(file)
+	return entry.Media, true
+}
+
+func (c *MediaCache) StoreMedia(path string, size int64, modTime time.Time, media MediaInfo) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{Path: path, Size: size, ModTime: modTime, Media: media})
+	if err != nil {
+		return
+	}
+
+	c.store(c.entryPath(cacheKey(path, size, modTime), ".json"), data)
+}
+
+func (c *MediaCache) LoadThumbnail(path string, size int64, modTime time.Time) (image.Image, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file := c.entryPath(cacheKey(path, size, modTime), ".png")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+
+	c.touch(file)
+	return img, true
+}
+
+func (c *MediaCache) StoreThumbnail(path string, size int64, modTime time.Time, img image.Image) {
+	if c == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return
+	}
+
+	c.store(c.entryPath(cacheKey(path, size, modTime), ".png"), buf.Bytes())
+}
+
+func (c *MediaCache) store(file string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// An entry written again replaces the old file
+	var old int64
+	if info, err := os.Stat(file); err == nil {
+		old = info.Size()
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		log.Printf("Failed to write cache entry: %v", err)
+		return
+	}
+	c.size += int64(len(data)) - old
+
+	// Only look at the files when they no longer fit
+	if c.size > c.maxSize {
+		c.evict()
+	}
+}
+
+// touch marks an entry as recently used for eviction.
+func (c *MediaCache) touch(file string) {
+	now := time.Now()
+	os.Chtimes(file, now, now)
+}
+
+// evict removes the least recently used files until the cache fits in
+// maxSize. The caller holds the lock.
+func (c *MediaCache) evict() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cacheFile
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		files = append(files, cacheFile{filepath.Join(c.dir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	c.size = total
+	if total <= c.maxSize {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+		}
+	}
+	c.size = total
+}
+
+// Size returns the total size of the cached files in bytes.
+func (c *MediaCache) Size() int64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// diskUsage adds up the size of the files in the cache directory.
+func (c *MediaCache) diskUsage() int64 {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0
+	}
+
+	var total int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// Clear deletes every cached entry.
+func (c *MediaCache) Clear() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Whatever couldn't be removed is still there
+	defer func() { c.size = c.diskUsage() }()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".png") {
+			if err := os.Remove(filepath.Join(c.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
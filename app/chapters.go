@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Chapter is a clip's section of the exported file.
+type Chapter struct {
+	Title      string
+	Start, End time.Duration
+}
+
+// ChapterName returns the title of the clip's chapter.
+func (v *Video) ChapterName() string {
+	if title := strings.TrimSpace(v.ChapterTitle); title != "" {
+		return title
+	}
+	return v.Name
+}
+
+// buildChapters returns a chapter per clip. A clip's chapter starts where
+// it first appears, so an overlapping transition belongs to the chapter of
+// the clip it leads into.
+func buildChapters(videos []*Video, boundaries []Transition) []Chapter {
+	chapters := make([]Chapter, len(videos))
+
+	var start time.Duration
+	for i, video := range videos {
+		chapters[i] = Chapter{Title: video.ChapterName(), Start: start}
+
+		start += video.TrimmedDuration()
+		if i < len(boundaries) {
+			start -= boundaries[i].Overlap()
+		}
+		if i > 0 {
+			chapters[i-1].End = chapters[i].Start
+		}
+	}
+	if len(chapters) > 0 {
+		chapters[len(chapters)-1].End = expectedDuration(videos, boundaries)
+	}
+
+	return chapters
+}
+
+// writeChapterMetadata writes chapters to a temporary file in ffmpeg's
+// metadata format, to be added as an input with -map_chapters.
+func writeChapterMetadata(chapters []Chapter) (string, error) {
+	f, err := os.CreateTemp("", "video-chapters-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, ";FFMETADATA1")
+	for _, c := range chapters {
+		fmt.Fprintf(f, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			c.Start.Milliseconds(), c.End.Milliseconds(), escapeMetadata(c.Title))
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// escapeMetadata escapes the characters that are special in ffmpeg's
+// metadata format.
+func escapeMetadata(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n").Replace(value)
+}
+
+// YouTubeChapters formats chapters for a YouTube video description: one
+// line per chapter with its start time, the first at 0:00.
+func YouTubeChapters(chapters []Chapter) string {
+	var b strings.Builder
+	for _, c := range chapters {
+		seconds := int(c.Start.Seconds())
+		h, m, s := seconds/3600, seconds/60%60, seconds%60
+		if h > 0 {
+			fmt.Fprintf(&b, "%d:%02d:%02d %s\n", h, m, s, c.Title)
+		} else {
+			fmt.Fprintf(&b, "%d:%02d %s\n", m, s, c.Title)
+		}
+	}
+	return b.String()
+}
+
+// YouTube ignores a chapter list unless it has at least youTubeMinChapters
+// chapters, each at least youTubeMinChapterLength long.
+const (
+	youTubeMinChapters      = 3
+	youTubeMinChapterLength = 10 * time.Second
+)
+
+// youTubeChapterProblem returns why YouTube would ignore the chapters, or
+// "" if it accepts them.
+func youTubeChapterProblem(chapters []Chapter) string {
+	if len(chapters) < youTubeMinChapters {
+		return fmt.Sprintf("YouTube needs at least %d chapters", youTubeMinChapters)
+	}
+	for _, c := range chapters {
+		if c.End-c.Start < youTubeMinChapterLength {
+			return fmt.Sprintf("chapter %q is shorter than the %gs YouTube requires", c.Title, youTubeMinChapterLength.Seconds())
+		}
+	}
+	return ""
+}
+
+// youTubeChaptersPath returns where the chapter list of an export is
+// written: next to it, e.g. "trip.chapters.txt" for "trip.mp4".
+func youTubeChaptersPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".chapters.txt"
+}
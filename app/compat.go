@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// StreamFormat describes the properties of a clip that must match across
+// all clips for the concat demuxer to join them without re-encoding.
+type StreamFormat struct {
+	VideoCodec  string
+	Width       int // displayed size, after rotation
+	Height      int
+	Rotation    int
+	FrameRate   float64
+	PixelFormat string
+	AudioCodec  string
+	SampleRate  int
+	Channels    int
+}
+
+// Canvas is the output format that mismatched clips are normalized to.
+// Zero fields are taken from the first clip.
+type Canvas struct {
+	Width     int     `json:"width,omitempty"`
+	Height    int     `json:"height,omitempty"`
+	FrameRate float64 `json:"frameRate,omitempty"`
+}
+
+func (c Canvas) String() string {
+	if c.FrameRate > 0 {
+		return fmt.Sprintf("%dx%d @ %.4gfps", c.Width, c.Height, c.FrameRate)
+	}
+	return fmt.Sprintf("%dx%d", c.Width, c.Height)
+}
+
+// ParseCanvas parses a canvas size such as "1920x1080". An empty string or
+// "auto" returns the zero Canvas.
+func ParseCanvas(s string) (Canvas, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "auto") {
+		return Canvas{}, nil
+	}
+
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	width, err1 := strconv.Atoi(w)
+	height, err2 := strconv.Atoi(h)
+	if !ok || err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return Canvas{}, fmt.Errorf("invalid canvas size %q", s)
+	}
+	return Canvas{Width: width, Height: height}, nil
+}
+
+const (
+	defaultFrameRate  = 30.0
+	defaultSampleRate = 48000
+)
+
+// defaultCanvas is used when there is no clip to take the size from, e.g.
+// a project of title cards only.
+var defaultCanvas = Canvas{Width: 1920, Height: 1080, FrameRate: defaultFrameRate}
+
+type ClipMismatch struct {
+	Video   *Video
+	Reasons []string
+}
+
+// CompatibilityReport is the result of comparing every clip against the
+// first one.
+type CompatibilityReport struct {
+	Reference  StreamFormat
+	Mismatches []ClipMismatch
+}
+
+func (r CompatibilityReport) Compatible() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Summary lists the clips that force a re-encode, one per line.
+func (r CompatibilityReport) Summary() string {
+	var lines []string
+	for _, m := range r.Mismatches {
+		lines = append(lines, fmt.Sprintf("%s: %s", m.Video.Name, strings.Join(m.Reasons, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ResolveCanvas fills the zero fields of canvas from the reference format.
+func (r CompatibilityReport) ResolveCanvas(canvas Canvas) Canvas {
+	if canvas.Width <= 0 || canvas.Height <= 0 {
+		canvas.Width, canvas.Height = r.Reference.Width, r.Reference.Height
+	}
+	if canvas.Width <= 0 || canvas.Height <= 0 {
+		canvas.Width, canvas.Height = defaultCanvas.Width, defaultCanvas.Height
+	}
+	if canvas.FrameRate <= 0 {
+		canvas.FrameRate = r.Reference.FrameRate
+	}
+	if canvas.FrameRate <= 0 {
+		canvas.FrameRate = defaultFrameRate
+	}
+	return canvas
+}
+
+func (r CompatibilityReport) SampleRate() int {
+	if r.Reference.SampleRate > 0 {
+		return r.Reference.SampleRate
+	}
+	return defaultSampleRate
+}
+
+// AnalyzeCompatibility compares every clip against the first one that
+// comes from a file. Title cards and clips with text overlays always have
+// to be rendered, so they are reported as mismatches too.
+func AnalyzeCompatibility(videos []*Video) (CompatibilityReport, error) {
+	var report CompatibilityReport
+	haveReference := false
+
+	for _, video := range videos {
+		if video.IsTitle() {
+			report.Mismatches = append(report.Mismatches, ClipMismatch{Video: video, Reasons: []string{"generated title card"}})
+			continue
+		}
+
+		if video.Media.VideoCodec == "" {
+			return report, fmt.Errorf("no stream information for %s", video.Name)
+		}
+		format := video.Media.StreamFormat()
+
+		var reasons []string
+		if !haveReference {
+			report.Reference = format
+			haveReference = true
+		} else {
+			reasons = compareFormats(report.Reference, format)
+		}
+		if len(video.Overlays) > 0 {
+			reasons = append(reasons, "text overlays")
+		}
+
+		if len(reasons) > 0 {
+			report.Mismatches = append(report.Mismatches, ClipMismatch{Video: video, Reasons: reasons})
+		}
+	}
+
+	return report, nil
+}
+
+func compareFormats(ref, f StreamFormat) []string {
+	var reasons []string
+
+	if f.VideoCodec != ref.VideoCodec {
+		reasons = append(reasons, fmt.Sprintf("video codec %s (expected %s)", f.VideoCodec, ref.VideoCodec))
+	}
+	if f.Width != ref.Width || f.Height != ref.Height {
+		reasons = append(reasons, fmt.Sprintf("resolution %dx%d (expected %dx%d)", f.Width, f.Height, ref.Width, ref.Height))
+	}
+	if f.Rotation != ref.Rotation {
+		// The concat demuxer keeps only the first clip's rotation metadata
+		reasons = append(reasons, fmt.Sprintf("rotated %d° (expected %d°)", f.Rotation, ref.Rotation))
+	}
+	if math.Abs(f.FrameRate-ref.FrameRate) > 0.01 {
+		reasons = append(reasons, fmt.Sprintf("frame rate %.4g (expected %.4g)", f.FrameRate, ref.FrameRate))
+	}
+	if f.PixelFormat != ref.PixelFormat {
+		reasons = append(reasons, fmt.Sprintf("pixel format %s (expected %s)", f.PixelFormat, ref.PixelFormat))
+	}
+
+	switch {
+	case f.AudioCodec == "" && ref.AudioCodec != "":
+		reasons = append(reasons, "no audio stream")
+	case f.AudioCodec != "" && ref.AudioCodec == "":
+		reasons = append(reasons, "has audio (first clip has none)")
+	default:
+		if f.AudioCodec != ref.AudioCodec {
+			reasons = append(reasons, fmt.Sprintf("audio codec %s (expected %s)", f.AudioCodec, ref.AudioCodec))
+		}
+		if f.SampleRate != ref.SampleRate {
+			reasons = append(reasons, fmt.Sprintf("sample rate %d (expected %d)", f.SampleRate, ref.SampleRate))
+		}
+		if f.Channels != ref.Channels {
+			reasons = append(reasons, fmt.Sprintf("%d audio channels (expected %d)", f.Channels, ref.Channels))
+		}
+	}
+
+	return reasons
+}
@@ -0,0 +1,477 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// EDLs are CMX3600 edit decision lists, the lowest common denominator for
+// handing a timeline to another editing system. Each clip is an event with
+// source and record timecodes, and overlapping transitions become
+// dissolves. The outgoing clip of a dissolve ends where the dissolve
+// starts and continues under it, so its out point is its event's source
+// out plus the dissolve.
+//
+// Source timecodes count frames at the rate of each clip's own media.
+// Record timecodes and dissolve lengths count them at the record rate, the
+// project's frame rate, which is noted in an edlRateComment below the
+// header so ImportEDL reads them back at the same rate.
+
+// edlRecordStart is where the record timecodes start, 01:00:00:00 by
+// convention.
+const edlRecordStart = 3600
+
+// edlBlackReel is the reel of generated black, which title cards are
+// written as.
+const edlBlackReel = "BL"
+
+// edlRateComment starts the comment line holding the record rate.
+const edlRateComment = "RECORD FRAME RATE:"
+
+// edlTimecode converts between durations and frames at a frame rate, and
+// formats frames as timecode at the nearest whole rate, which is how
+// non-drop-frame timecode counts e.g. 29.97 fps.
+type edlTimecode struct {
+	frameRate float64
+	base      int
+}
+
+func newEDLTimecode(frameRate float64) edlTimecode {
+	if frameRate <= 0 {
+		frameRate = defaultFrameRate
+	}
+	return edlTimecode{frameRate: frameRate, base: max(1, int(math.Round(frameRate)))}
+}
+
+func (tc edlTimecode) frames(seconds float64) int {
+	return int(math.Round(seconds * tc.frameRate))
+}
+
+// seconds returns the time of a frame, to the millisecond.
+func (tc edlTimecode) seconds(frames int) float64 {
+	return math.Round(float64(frames)/tc.frameRate*1000) / 1000
+}
+
+// count returns the frame number of a timecode read from an EDL.
+func (tc edlTimecode) count(t edlTime) int {
+	return t.seconds*tc.base + t.frames
+}
+
+func (tc edlTimecode) format(frames int) string {
+	ff := frames % tc.base
+	seconds := frames / tc.base
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60, ff)
+}
+
+// edlTime is a timecode read from an EDL: whole seconds and frames, before
+// the rate it counts frames at is known.
+type edlTime struct {
+	seconds, frames int
+}
+
+// parseEDLTime reads hh:mm:ss:ff, also with the ';' of drop-frame
+// timecode, which is counted as non-drop-frame.
+func parseEDLTime(s string) (edlTime, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ';' || r == '.' })
+	if len(parts) != 4 {
+		return edlTime{}, fmt.Errorf("invalid timecode %q", s)
+	}
+
+	var values [4]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return edlTime{}, fmt.Errorf("invalid timecode %q", s)
+		}
+		values[i] = value
+	}
+	return edlTime{seconds: (values[0]*60+values[1])*60 + values[2], frames: values[3]}, nil
+}
+
+// after reports whether t is later than u.
+func (t edlTime) after(u edlTime) bool {
+	return t.seconds > u.seconds || t.seconds == u.seconds && t.frames > u.frames
+}
+
+// edlFrameRate is the record rate of an EDL of videos: the export frame
+// rate if one is set, otherwise the first video clip's.
+func edlFrameRate(videos []*Video, options ExportOptions) float64 {
+	if options.Canvas.FrameRate > 0 {
+		return options.Canvas.FrameRate
+	}
+	for _, video := range videos {
+		if !video.IsTitle() && video.Media.FrameRate > 0 {
+			return video.Media.FrameRate
+		}
+	}
+	return defaultFrameRate
+}
+
+// edlReelName returns the reel name of a file: its name without extension,
+// cut to the eight letters, digits and underscores a CMX3600 reel allows.
+func edlReelName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	reel := []rune(strings.Map(func(r rune) rune {
+		if r < 128 && (r == '_' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return r
+		}
+		return '_'
+	}, name))
+	if len(reel) > 8 {
+		reel = reel[:8]
+	}
+	if len(reel) == 0 {
+		return "AX"
+	}
+	return string(reel)
+}
+
+// FormatEDL writes videos as a CMX3600 EDL. Fades through black, which
+// don't overlap the clips, are written as cuts.
+func FormatEDL(title string, videos []*Video, options ExportOptions) string {
+	tc := newEDLTimecode(edlFrameRate(videos, options))
+	boundaries := boundaryTransitions(videos, options)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "TITLE: %s\nFCM: NON-DROP FRAME\n* %s %g\n\n", title, edlRateComment, tc.frameRate)
+
+	event := 0
+	writeEvent := func(reel, track, kind string, dissolve int, src edlTimecode, srcIn, srcOut, recIn, recOut int) {
+		duration := ""
+		if kind != "C" {
+			duration = fmt.Sprintf("%03d", dissolve)
+		}
+		fmt.Fprintf(&b, "%03d  %-8s %-5s %-4s %3s %s %s %s %s\n", event, reel, track, kind, duration,
+			src.format(srcIn), src.format(srcOut), tc.format(recIn), tc.format(recOut))
+	}
+
+	// The record position is kept in seconds from the start, so rounding
+	// to frames at different rates doesn't add up
+	recordStart := edlRecordStart * tc.base
+	var record float64
+	var last struct {
+		reel, track string
+		src         edlTimecode
+		srcOut      int
+	}
+	for i, video := range videos {
+		reel, track, name := edlReelName(video.Path), "V", filepath.Base(video.Path)
+		if video.HasAudio {
+			track = "AA/V"
+		}
+		src := tc
+		if video.Media.FrameRate > 0 {
+			src = newEDLTimecode(video.Media.FrameRate)
+		}
+		in, out := video.InPoint.Seconds(), video.EffectiveOutPoint().Seconds()
+		if video.IsTitle() {
+			reel, track, name = edlBlackReel, "V", strings.ReplaceAll(video.Title.Text, "\n", " ")
+			src, in, out = tc, 0, video.Title.Duration
+		}
+
+		// The clip continues under the dissolve into the next one
+		if i < len(boundaries) {
+			out -= boundaries[i].Overlap().Seconds()
+		}
+		srcIn, srcOut := src.frames(in), src.frames(out)
+		recIn, recOut := recordStart+tc.frames(record), recordStart+tc.frames(record+out-in)
+
+		event++
+		dissolve := 0
+		if i > 0 {
+			dissolve = tc.frames(boundaries[i-1].Overlap().Seconds())
+		}
+		if dissolve > 0 {
+			// The outgoing clip as a zero-length event, then the dissolve
+			// into this one
+			writeEvent(last.reel, last.track, "C", 0, last.src, last.srcOut, last.srcOut, recIn, recIn)
+			writeEvent(reel, track, "D", dissolve, src, srcIn, srcOut, recIn, recOut)
+		} else {
+			writeEvent(reel, track, "C", 0, src, srcIn, srcOut, recIn, recOut)
+		}
+		fmt.Fprintf(&b, "* FROM CLIP NAME: %s\n", name)
+		if !video.IsTitle() {
+			fmt.Fprintf(&b, "* SOURCE FILE: %s\n", video.Path)
+		}
+		b.WriteString("\n")
+
+		record += out - in
+		last.reel, last.track, last.src, last.srcOut = reel, track, src, srcOut
+	}
+
+	return b.String()
+}
+
+// ExportEDL writes videos to path as a CMX3600 EDL.
+func ExportEDL(path, title string, videos []*Video, options ExportOptions) error {
+	if len(videos) == 0 {
+		return fmt.Errorf("no videos to export")
+	}
+	return os.WriteFile(path, []byte(FormatEDL(title, videos, options)), 0644)
+}
+
+// edlEvent is a video event read from an EDL.
+type edlEvent struct {
+	reel          string
+	kind          string // "C" for a cut, "D" for a dissolve, "W..." for a wipe
+	dissolve      int    // length of the transition into the event, in record frames
+	srcIn, srcOut edlTime
+	recIn, recOut edlTime
+	clipName      string
+}
+
+// edlList is what parseEDL reads from an EDL.
+type edlList struct {
+	title     string
+	frameRate float64 // record rate from the edlRateComment, zero if there is none
+	events    []edlEvent
+}
+
+// parseEDL reads the title, the record rate and the video events of a
+// CMX3600 EDL.
+func parseEDL(data string) (edlList, error) {
+	var list edlList
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(text, "TITLE:"):
+			list.title = strings.TrimSpace(strings.TrimPrefix(text, "TITLE:"))
+			continue
+		case strings.HasPrefix(text, "*"):
+			comment := strings.TrimSpace(strings.TrimPrefix(text, "*"))
+			if rate, ok := strings.CutPrefix(comment, edlRateComment); ok && len(list.events) == 0 {
+				if value, err := strconv.ParseFloat(strings.TrimSpace(rate), 64); err == nil && value > 0 {
+					list.frameRate = value
+				}
+				continue
+			}
+			// After a dissolve, the clip dissolved to is named last
+			for _, prefix := range []string{"FROM CLIP NAME:", "TO CLIP NAME:"} {
+				if name, ok := strings.CutPrefix(comment, prefix); ok && len(list.events) > 0 {
+					list.events[len(list.events)-1].clipName = strings.TrimSpace(name)
+				}
+			}
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 8 {
+			// FCM lines, motion effects, blank lines and the like
+			continue
+		}
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			continue
+		}
+		if !strings.Contains(strings.ToUpper(fields[2]), "V") && strings.ToUpper(fields[2]) != "B" {
+			// Audio only
+			continue
+		}
+
+		e := edlEvent{reel: fields[1], kind: strings.ToUpper(fields[3])}
+		timecodes := fields[len(fields)-4:]
+		if len(fields) >= 9 && e.kind != "C" {
+			dissolve, err := strconv.Atoi(fields[4])
+			if err != nil {
+				return edlList{}, fmt.Errorf("line %d: invalid transition duration %q", line, fields[4])
+			}
+			e.dissolve = dissolve
+		}
+		for i, value := range []*edlTime{&e.srcIn, &e.srcOut, &e.recIn, &e.recOut} {
+			t, err := parseEDLTime(timecodes[i])
+			if err != nil {
+				return edlList{}, fmt.Errorf("line %d: %w", line, err)
+			}
+			*value = t
+		}
+		list.events = append(list.events, e)
+	}
+
+	return list, scanner.Err()
+}
+
+// ImportEDL rebuilds a project from a CMX3600 EDL. Clips are looked up in
+// mediaFolder and its subfolders, by clip name where the EDL has one and
+// otherwise by reel name. Clips that aren't found are added offline under
+// mediaFolder so they can be relinked.
+//
+// EDL timecodes are in frames. Source timecodes are counted at the frame
+// rate of each clip found, and record timecodes and dissolves at frameRate.
+// If that is zero, the record rate noted by FormatEDL is used, or for EDLs
+// from elsewhere the frame rate of the first clip found. Clips that aren't
+// found are counted at the record rate too. Dissolves become crossfades and
+// black becomes title cards.
+func ImportEDL(path, mediaFolder string, frameRate float64) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := parseEDL(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := findEDLMedia(list.events, mediaFolder)
+	if err != nil {
+		return nil, err
+	}
+	if frameRate <= 0 {
+		frameRate = list.frameRate
+	}
+	if frameRate <= 0 {
+		frameRate = mediaFrameRate(list.events, media)
+	}
+	tc := newEDLTimecode(frameRate)
+
+	// Each file is probed once, however often it is used
+	sources := make(map[string]edlTimecode)
+	sourceTimecode := func(path string) edlTimecode {
+		src, ok := sources[path]
+		if !ok {
+			src = tc
+			if rate := probeFrameRate(path); rate > 0 {
+				src = newEDLTimecode(rate)
+			}
+			sources[path] = src
+		}
+		return src
+	}
+
+	var clips []ProjectClip
+	for _, e := range list.events {
+		if !e.srcOut.after(e.srcIn) {
+			// The outgoing side of a dissolve, which continues the
+			// previous clip
+			continue
+		}
+
+		if e.dissolve > 0 && e.kind != "C" && len(clips) > 0 {
+			// The previous clip runs under the dissolve
+			prev := &clips[len(clips)-1]
+			duration := tc.seconds(e.dissolve)
+			prev.Transition = &Transition{Type: TransitionCrossfade, Duration: duration}
+			if prev.Title != nil {
+				prev.Title.Duration += duration
+			} else {
+				prev.OutPoint += duration
+			}
+		}
+
+		if strings.EqualFold(e.reel, edlBlackReel) {
+			card := DefaultTitleCard()
+			card.Text = e.clipName
+			card.Duration = tc.seconds(tc.count(e.srcOut) - tc.count(e.srcIn))
+			clips = append(clips, ProjectClip{Title: &card})
+			continue
+		}
+
+		src := tc
+		clip := ProjectClip{Path: media[e.key()]}
+		if clip.Path == "" {
+			clip.Path = filepath.Join(mediaFolder, e.name())
+		} else {
+			src = sourceTimecode(clip.Path)
+		}
+		clip.InPoint = src.seconds(src.count(e.srcIn))
+		clip.OutPoint = src.seconds(src.count(e.srcOut))
+		clips = append(clips, clip)
+	}
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("no video events in %s", filepath.Base(path))
+	}
+
+	title := list.title
+	if title == "" {
+		title = projectNameFromPath(path)
+	}
+	project := &Project{
+		Version:  ProjectVersion,
+		Name:     title,
+		Clips:    clips,
+		Selected: -1,
+		Export:   ExportOptions{TransitionDuration: 1.0},
+	}
+	return project, nil
+}
+
+// name returns the file an event's clip is looked up by.
+func (e edlEvent) name() string {
+	if e.clipName != "" {
+		return e.clipName
+	}
+	return e.reel
+}
+
+// key identifies the event's clip in the map returned by findEDLMedia.
+func (e edlEvent) key() string {
+	return strings.ToLower(e.name())
+}
+
+// findEDLMedia searches folder for the clips of events, matching the clip
+// name against file names and a reel name against file names without
+// extension, or their reel name. It returns the path of each clip found by
+// event key.
+func findEDLMedia(events []edlEvent, folder string) (map[string]string, error) {
+	wanted := make(map[string]bool)
+	for _, e := range events {
+		if !strings.EqualFold(e.reel, edlBlackReel) {
+			wanted[e.key()] = true
+		}
+	}
+
+	found := make(map[string]string)
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			// Skip unreadable folders rather than giving up
+			return nil
+		}
+
+		name := d.Name()
+		for _, key := range []string{
+			strings.ToLower(name),
+			strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))),
+			strings.ToLower(edlReelName(name)),
+		} {
+			if _, ok := found[key]; wanted[key] && !ok {
+				found[key] = path
+			}
+		}
+		return nil
+	})
+
+	return found, err
+}
+
+// mediaFrameRate returns the frame rate of the first clip of events that
+// was found, or the default if none can be probed.
+func mediaFrameRate(events []edlEvent, media map[string]string) float64 {
+	for _, e := range events {
+		path, ok := media[e.key()]
+		if !ok {
+			continue
+		}
+		if rate := probeFrameRate(path); rate > 0 {
+			return rate
+		}
+	}
+	return defaultFrameRate
+}
+
+// probeFrameRate returns the frame rate of a media file, or zero if it
+// can't be probed. It is a variable so tests can do without ffprobe.
+var probeFrameRate = func(path string) float64 {
+	info, err := ProbeMedia(path)
+	if err != nil {
+		return 0
+	}
+	return info.FrameRate
+}
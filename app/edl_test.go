@@ -0,0 +1,86 @@
+package app
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestEDLRoundTripMixedFrameRates exports clips whose frame rates differ
+// from the project's and from each other, and checks that importing the
+// EDL restores their trims and the dissolve between them.
+func TestEDLRoundTripMixedFrameRates(t *testing.T) {
+	dir := t.TempDir()
+	rates := map[string]float64{
+		filepath.Join(dir, "a.mp4"): 25,
+		filepath.Join(dir, "b.mov"): 24,
+	}
+	for path := range rates {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved := probeFrameRate
+	probeFrameRate = func(path string) float64 { return rates[path] }
+	t.Cleanup(func() { probeFrameRate = saved })
+
+	videos := []*Video{
+		{
+			Path:          filepath.Join(dir, "a.mp4"),
+			Name:          "a.mp4",
+			Duration:      10 * time.Second,
+			InPoint:       2400 * time.Millisecond,
+			OutPoint:      6 * time.Second,
+			HasAudio:      true,
+			Media:         MediaInfo{FrameRate: 25},
+			TransitionOut: &Transition{Type: TransitionCrossfade, Duration: 1},
+		},
+		{
+			Path:     filepath.Join(dir, "b.mov"),
+			Name:     "b.mov",
+			Duration: 8 * time.Second,
+			InPoint:  1500 * time.Millisecond,
+			OutPoint: 5500 * time.Millisecond,
+			Media:    MediaInfo{FrameRate: 24},
+		},
+	}
+	options := ExportOptions{Transition: TransitionNone, TransitionDuration: 1, Canvas: Canvas{FrameRate: 30}}
+
+	edlPath := filepath.Join(dir, "timeline.edl")
+	if err := ExportEDL(edlPath, "Timeline", videos, options); err != nil {
+		t.Fatal(err)
+	}
+	project, err := ImportEDL(edlPath, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(project.Clips) != len(videos) {
+		t.Fatalf("got %d clips, want %d", len(project.Clips), len(videos))
+	}
+	for i, video := range videos {
+		clip := project.Clips[i]
+		if clip.Path != video.Path {
+			t.Errorf("clip %d: path %q, want %q", i, clip.Path, video.Path)
+		}
+		if !closeSeconds(clip.InPoint, video.InPoint.Seconds()) || !closeSeconds(clip.OutPoint, video.OutPoint.Seconds()) {
+			t.Errorf("clip %d: trimmed to %g-%g, want %g-%g", i,
+				clip.InPoint, clip.OutPoint, video.InPoint.Seconds(), video.OutPoint.Seconds())
+		}
+	}
+
+	transition := project.Clips[0].Transition
+	if transition == nil || transition.Type != TransitionCrossfade || !closeSeconds(transition.Duration, 1) {
+		t.Errorf("transition after the first clip is %v, want a 1s crossfade", transition)
+	}
+	if project.Clips[1].Transition != nil {
+		t.Errorf("transition after the last clip is %v, want none", project.Clips[1].Transition)
+	}
+}
+
+func closeSeconds(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
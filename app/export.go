@@ -1,44 +1,48 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
-type TransitionType int
-
-const (
-	TransitionNone TransitionType = iota
-	TransitionFade
-	TransitionCrossfade
-)
-
-func (t TransitionType) String() string {
-	switch t {
-	case TransitionFade:
-		return "Fade"
-	case TransitionCrossfade:
-		return "Crossfade"
-	default:
-		return "None"
-	}
-}
-
 type ExportOptions struct {
-	Transition         TransitionType
-	TransitionDuration float64 // in seconds
+	Transition         TransitionType `json:"transition"`
+	TransitionDuration float64        `json:"transitionDuration"` // in seconds
+	Canvas             Canvas         `json:"canvas,omitzero"`    // used when clips have to be re-encoded to match
+	Music              MusicBed       `json:"music,omitzero"`
+	Loudness           Loudness       `json:"loudness,omitzero"`
+	Subtitles          SubtitleMode   `json:"subtitles,omitempty"`
 }
 
 type ExportProgress struct {
-	Status string
-	Done   bool
-	Error  error
+	Status  string
+	Detail  string  // extra information worth keeping on screen, e.g. why clips are re-encoded
+	Percent float64 // 0-100, zero while unknown
+	Elapsed time.Duration
+	ETA     time.Duration
+	Done    bool
+	// Cancelled is set on the final update when the export was aborted
+	// through its context; the partial output file has been removed.
+	Cancelled bool
+	Error     error
+}
+
+func (p ExportProgress) String() string {
+	if p.Percent <= 0 || p.Done {
+		return p.Status
+	}
+	if p.ETA > 0 {
+		return fmt.Sprintf("%s %.0f%% (elapsed %s, ETA %s)", p.Status, p.Percent, FormatTimecode(p.Elapsed), FormatTimecode(p.ETA))
+	}
+	return fmt.Sprintf("%s %.0f%% (elapsed %s)", p.Status, p.Percent, FormatTimecode(p.Elapsed))
 }
 
-func ExportVideos(videos []*Video, outputPath string, options ExportOptions, progress chan<- ExportProgress) {
+func ExportVideos(ctx context.Context, videos []*Video, outputPath string, options ExportOptions, progress chan<- ExportProgress) {
 	defer close(progress)
 
 	if len(videos) == 0 {
@@ -46,189 +50,428 @@ func ExportVideos(videos []*Video, outputPath string, options ExportOptions, pro
 		return
 	}
 
+	for _, video := range videos {
+		switch video.Status {
+		case VideoProbing:
+			progress <- ExportProgress{Error: fmt.Errorf("%s is still loading", video.Name)}
+			return
+		case VideoOffline:
+			progress <- ExportProgress{Error: fmt.Errorf("%s is offline, relink it first", video.Name)}
+			return
+		case VideoFailed:
+			progress <- ExportProgress{Error: fmt.Errorf("%s could not be loaded: %v", video.Name, video.LoadErr)}
+			return
+		}
+	}
+
+	if err := options.Music.check(); err != nil {
+		progress <- ExportProgress{Error: err}
+		return
+	}
+	if err := options.Loudness.Validate(); err != nil {
+		progress <- ExportProgress{Error: err}
+		return
+	}
+
 	progress <- ExportProgress{Status: "Preparing export..."}
 
-	if options.Transition == TransitionNone || len(videos) == 1 {
-		exportSimple(videos, outputPath, progress)
+	report, err := AnalyzeCompatibility(videos)
+	if err != nil {
+		progress <- ExportProgress{Error: err}
+		return
+	}
+
+	var norm *normalization
+	if !report.Compatible() {
+		norm = newNormalization(report, options.Canvas)
+		progress <- ExportProgress{
+			Status: "Clips differ in format, re-encoding to " + norm.canvas.String(),
+			Detail: "These clips forced a re-encode:\n" + report.Summary(),
+		}
+	}
+
+	boundaries := boundaryTransitions(videos, options)
+	mix := &audioMix{
+		music:      newMusicMix(options, report.SampleRate(), expectedDuration(videos, boundaries).Seconds()),
+		sampleRate: report.SampleRate(),
+	}
+	// Each clip becomes a chapter of the output
+	chapters := buildChapters(videos, boundaries)
+	extras := &exportExtras{}
+	if len(chapters) > 1 {
+		if extras.chapters, err = writeChapterMetadata(chapters); err != nil {
+			progress <- ExportProgress{Error: err}
+			return
+		}
+		defer os.Remove(extras.chapters)
+	}
+
+	subtitleMode, subtitles, subtitlesFile, err := prepareSubtitles(ctx, options.Subtitles, videos, chapters, outputPath, progress)
+	if subtitlesFile != "" {
+		defer os.Remove(subtitlesFile)
+		if subtitleMode == SubtitlesBurn {
+			extras.burn = subtitlesFile
+		} else {
+			extras.subtitles = subtitlesFile
+		}
+	}
+	if extras.burn != "" && norm == nil {
+		norm = newNormalization(report, options.Canvas)
+		progress <- ExportProgress{Status: "Burning in subtitles, re-encoding to " + norm.canvas.String()}
+	}
+
+	if err == nil && options.Loudness.Scope == LoudnessClip {
+		mix.clipLoudness, err = measureClips(ctx, videos, options.Loudness, mix.sampleRate, progress)
+	}
+	if err == nil {
+		err = render(ctx, videos, outputPath, boundaries, norm, mix, extras, options.Loudness, progress)
+	}
+
+	if ctx.Err() != nil {
+		os.Remove(outputPath)
+		progress <- ExportProgress{Status: "Export cancelled", Cancelled: true}
+		return
+	}
+	if err != nil {
+		progress <- ExportProgress{Error: err}
+		return
+	}
+
+	var written []string
+	if problem := youTubeChapterProblem(chapters); problem != "" {
+		if len(chapters) > 1 {
+			written = append(written, "No YouTube chapter list written: "+problem)
+		}
 	} else {
-		exportWithTransitions(videos, outputPath, options, progress)
+		chaptersPath := youTubeChaptersPath(outputPath)
+		if err := os.WriteFile(chaptersPath, []byte(YouTubeChapters(chapters)), 0644); err != nil {
+			progress <- ExportProgress{Error: fmt.Errorf("failed to write the chapter list: %w", err)}
+			return
+		}
+		written = append(written, "YouTube chapters written to "+chaptersPath)
 	}
+	if subtitleMode == SubtitlesSidecar {
+		subtitlesPath := sidecarSubtitlesPath(outputPath)
+		if err := os.WriteFile(subtitlesPath, subtitles, 0644); err != nil {
+			progress <- ExportProgress{Error: fmt.Errorf("failed to write the subtitles: %w", err)}
+			return
+		}
+		written = append(written, "Subtitles written to "+subtitlesPath)
+	}
+	detail := strings.Join(written, "\n")
+
+	progress <- ExportProgress{Status: "Export complete!", Detail: detail, Percent: 100, Done: true}
 }
 
-func exportSimple(videos []*Video, outputPath string, progress chan<- ExportProgress) {
+// render renders the clips to outputPath. When the loudness of the whole
+// program is normalized, they are rendered to an intermediate file first
+// and measured. Without clip audio or music there is nothing to measure,
+// and joined clips might not even have an audio stream, so they are
+// rendered directly.
+func render(ctx context.Context, videos []*Video, outputPath string, boundaries []Transition, norm *normalization, mix *audioMix, extras *exportExtras, loudness Loudness, progress chan<- ExportProgress) error {
+	hasAudio := mix.music != nil || slices.ContainsFunc(videos, func(v *Video) bool { return v.HasAudio })
+	if loudness.Scope != LoudnessProgram || !hasAudio {
+		return renderClips(ctx, videos, outputPath, boundaries, norm, mix, extras, progress)
+	}
+
+	intermediate, err := intermediateFile(outputPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(intermediate)
+
+	mix.intermediate = true
+	if err := renderClips(ctx, videos, intermediate, boundaries, norm, mix, extras, progress); err != nil {
+		return err
+	}
+	return normalizeProgram(ctx, intermediate, outputPath, loudness, mix.sampleRate, expectedDuration(videos, boundaries), progress)
+}
+
+func renderClips(ctx context.Context, videos []*Video, outputPath string, boundaries []Transition, norm *normalization, mix *audioMix, extras *exportExtras, progress chan<- ExportProgress) error {
+	if allCuts(boundaries) {
+		return exportSimple(ctx, videos, outputPath, norm, mix, extras, progress)
+	}
+	return exportWithTransitions(ctx, videos, outputPath, boundaries, norm, mix, extras, progress)
+}
+
+func exportSimple(ctx context.Context, videos []*Video, outputPath string, norm *normalization, mix *audioMix, extras *exportExtras, progress chan<- ExportProgress) error {
+	if norm != nil {
+		return exportNormalized(ctx, videos, outputPath, norm, mix, extras, progress)
+	}
+
 	tmpFile, err := os.CreateTemp("", "video-list-*.txt")
 	if err != nil {
-		progress <- ExportProgress{Error: fmt.Errorf("failed to create temp file: %w", err)}
-		return
+		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 	defer os.Remove(tmpFile.Name())
 
 	for _, video := range videos {
 		escaped := strings.ReplaceAll(video.Path, "'", "'\\''")
 		fmt.Fprintf(tmpFile, "file '%s'\n", escaped)
+		if video.InPoint > 0 {
+			fmt.Fprintf(tmpFile, "inpoint %.3f\n", video.InPoint.Seconds())
+		}
+		if video.OutPoint > 0 && video.OutPoint < video.Duration {
+			fmt.Fprintf(tmpFile, "outpoint %.3f\n", video.OutPoint.Seconds())
+		}
 	}
 	tmpFile.Close()
 
-	progress <- ExportProgress{Status: "Combining videos..."}
-
-	ext := strings.ToLower(filepath.Ext(outputPath))
 	args := []string{
 		"-f", "concat",
 		"-safe", "0",
 		"-i", tmpFile.Name(),
 	}
 
-	if ext == ".mp4" || ext == ".mov" || ext == ".m4v" {
-		args = append(args, "-c", "copy", "-movflags", "+faststart")
+	args = append(args, mix.inputArgs()...)
+	args = append(args, extras.inputArgs()...)
+
+	// Music and loudness need the audio re-encoded, but the video can
+	// still be copied
+	if mix.filtersAudio() {
+		var chains []string
+		program := "[0:a]"
+		switch {
+		case !slices.ContainsFunc(videos, func(v *Video) bool { return v.HasAudio }):
+			// The joined clips have no audio stream, so the music plays
+			// over silence
+			chains = []string{fmt.Sprintf("anullsrc=channel_layout=stereo:sample_rate=%d,atrim=duration=%.3f[silence]",
+				mix.sampleRate, expectedDuration(videos, nil).Seconds())}
+			program = "[silence]"
+		case mix.normalizesClips():
+			chains, program = mix.splitClips(videos, program), "[aclips]"
+		}
+		audioChains, audio := mix.filters(program, 1)
+		args = append(args, "-filter_complex", strings.Join(append(chains, audioChains...), ";"),
+			"-map", "0:v", "-map", audio, "-c:v", "copy")
 	} else {
+		if extras.subtitles != "" {
+			// Mapping the subtitles turns off the default stream selection
+			args = append(args, "-map", "0:v", "-map", "0:a?")
+		}
 		args = append(args, "-c", "copy")
 	}
 
-	args = append(args, "-y", outputPath)
+	args = append(args, extras.mapArgs(1+mix.inputCount(), outputPath)...)
+	args = append(args, mix.codecArgs()...)
+	args = append(args, outputArgs(outputPath)...)
 
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		progress <- ExportProgress{Error: fmt.Errorf("ffmpeg error: %w\n%s", err, string(output))}
-		return
+	return runFFmpeg(ctx, args, expectedDuration(videos, nil), "Combining videos...", progress)
+}
+
+// exportNormalized joins clips with the concat filter instead of the concat
+// demuxer, re-encoding every clip to the common format.
+func exportNormalized(ctx context.Context, videos []*Video, outputPath string, norm *normalization, mix *audioMix, extras *exportExtras, progress chan<- ExportProgress) error {
+	var args []string
+	for _, video := range videos {
+		args = append(args, inputArgs(video, norm)...)
 	}
+	args = append(args, mix.inputArgs()...)
+	args = append(args, extras.inputArgs()...)
+
+	inputs := buildStreamInputs(videos, norm)
+	mix.normalizeClips(&inputs)
+	args = append(args, filterArgs(buildConcatFilter(videos, inputs), mix, extras, len(videos))...)
+
+	// Frames are already upright, so don't carry over rotation metadata
+	args = append(args, "-metadata:s:v:0", "rotate=0")
+	args = append(args, extras.mapArgs(len(videos)+mix.inputCount(), outputPath)...)
+	args = append(args, mix.codecArgs()...)
+	args = append(args, outputArgs(outputPath)...)
 
-	progress <- ExportProgress{Status: "Export complete!", Done: true}
+	return runFFmpeg(ctx, args, expectedDuration(videos, nil), "Re-encoding videos...", progress)
 }
 
-func exportWithTransitions(videos []*Video, outputPath string, options ExportOptions, progress chan<- ExportProgress) {
+func exportWithTransitions(ctx context.Context, videos []*Video, outputPath string, boundaries []Transition, norm *normalization, mix *audioMix, extras *exportExtras, progress chan<- ExportProgress) error {
 	progress <- ExportProgress{Status: "Building transition filters..."}
 
-	duration := options.TransitionDuration
-	if duration <= 0 {
-		duration = 1.0
-	}
-
-	// Build ffmpeg command with xfade filter for crossfade
-	// or fade filter for fade in/out
 	var args []string
 
-	// Add all input files
+	// Add all input files, seeking to each clip's trim points
 	for _, video := range videos {
-		args = append(args, "-i", video.Path)
+		args = append(args, inputArgs(video, norm)...)
 	}
+	args = append(args, mix.inputArgs()...)
+	args = append(args, extras.inputArgs()...)
+
+	inputs := buildStreamInputs(videos, norm)
+	mix.normalizeClips(&inputs)
+	chains := buildTransitionFilter(videos, inputs, boundaries, outputFrameRate(videos, norm))
+	args = append(args, filterArgs(chains, mix, extras, len(videos))...)
+
+	// Frames are already upright, so don't carry over rotation metadata
+	args = append(args, "-metadata:s:v:0", "rotate=0")
+	args = append(args, extras.mapArgs(len(videos)+mix.inputCount(), outputPath)...)
+	args = append(args, mix.codecArgs()...)
+	args = append(args, outputArgs(outputPath)...)
+
+	return runFFmpeg(ctx, args, expectedDuration(videos, boundaries), "Rendering with transitions...", progress)
+}
+
+// exportExtras are the files added to the clips on export besides the
+// music.
+type exportExtras struct {
+	chapters  string // chapters in ffmpeg's metadata format
+	subtitles string // SubRip file muxed as a subtitle track
+	burn      string // SubRip file drawn into the picture
+}
 
-	if options.Transition == TransitionCrossfade {
-		args = append(args, buildCrossfadeFilter(videos, duration)...)
-	} else if options.Transition == TransitionFade {
-		args = append(args, buildFadeFilter(videos, duration)...)
+// inputArgs returns the inputs added after the clips and the music.
+func (e *exportExtras) inputArgs() []string {
+	var args []string
+	if e.chapters != "" {
+		args = append(args, "-f", "ffmetadata", "-i", e.chapters)
+	}
+	if e.subtitles != "" {
+		args = append(args, "-i", e.subtitles)
+	}
+	return args
+}
+
+// mapArgs takes the chapters and the subtitle track from the inputs added
+// by inputArgs, the first of which is input number firstInput.
+func (e *exportExtras) mapArgs(firstInput int, outputPath string) []string {
+	var args []string
+	index := firstInput
+	if e.chapters != "" {
+		args = append(args, "-map_chapters", fmt.Sprint(index))
+		index++
+	}
+	if e.subtitles != "" {
+		args = append(args, "-map", fmt.Sprintf("%d:s", index), "-c:s", subtitleCodec(outputPath))
 	}
+	return args
+}
 
+// outputArgs returns the options that write the output file, moving the
+// index to the front of MP4 and QuickTime files so they start playing
+// before they are fully downloaded.
+func outputArgs(outputPath string) []string {
+	var args []string
 	ext := strings.ToLower(filepath.Ext(outputPath))
 	if ext == ".mp4" || ext == ".mov" || ext == ".m4v" {
 		args = append(args, "-movflags", "+faststart")
 	}
+	return append(args, "-y", outputPath)
+}
 
-	args = append(args, "-y", outputPath)
-
-	progress <- ExportProgress{Status: "Rendering with transitions..."}
+// expectedDuration returns the length of the exported file, used to turn
+// ffmpeg's output time into a percentage. boundaries are the transitions
+// between the clips, nil if they are simply joined.
+func expectedDuration(videos []*Video, boundaries []Transition) time.Duration {
+	var total time.Duration
+	for _, video := range videos {
+		total += video.TrimmedDuration()
+	}
 
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		progress <- ExportProgress{Error: fmt.Errorf("ffmpeg error: %w\n%s", err, string(output))}
-		return
+	// Overlapping transitions shorten the output by their duration
+	for _, t := range boundaries {
+		total -= t.Overlap()
 	}
 
-	progress <- ExportProgress{Status: "Export complete!", Done: true}
+	return total
 }
 
-func buildCrossfadeFilter(videos []*Video, duration float64) []string {
-	n := len(videos)
-	if n < 2 {
-		return nil
+// outputFrameRate is the frame rate the filter graph runs at.
+func outputFrameRate(videos []*Video, norm *normalization) float64 {
+	if norm != nil {
+		return norm.canvas.FrameRate
 	}
-
-	var filterParts []string
-	var audioFilterParts []string
-
-	// Calculate offsets for each transition
-	offsets := make([]float64, n-1)
-	cumulative := 0.0
-	for i := 0; i < n-1; i++ {
-		cumulative += videos[i].Duration.Seconds() - duration
-		offsets[i] = cumulative
+	if len(videos) > 0 && videos[0].Media.FrameRate > 0 {
+		return videos[0].Media.FrameRate
 	}
+	return defaultFrameRate
+}
 
-	// Build video xfade chain
-	lastVideo := "[0:v]"
-	for i := 1; i < n; i++ {
-		outputLabel := fmt.Sprintf("[v%d]", i)
-		if i == n-1 {
-			outputLabel = "[vout]"
+// inputArgs returns the ffmpeg input options for a video. Seeking before -i
+// makes the input start at the in point, so filter timestamps are relative
+// to the trimmed clip. Autorotation turns rotated phone clips upright
+// before they reach the filter graph.
+// Title cards are generated at the canvas size by a lavfi input.
+func inputArgs(video *Video, norm *normalization) []string {
+	if video.IsTitle() {
+		canvas := defaultCanvas
+		if norm != nil {
+			canvas = norm.canvas
 		}
-		filterParts = append(filterParts,
-			fmt.Sprintf("%s[%d:v]xfade=transition=fade:duration=%.2f:offset=%.2f%s",
-				lastVideo, i, duration, offsets[i-1], outputLabel))
-		lastVideo = outputLabel
+		return []string{"-f", "lavfi", "-i", titleSource(*video.Title, canvas.Width, canvas.Height, canvas.FrameRate)}
 	}
 
-	// Build audio crossfade chain
-	lastAudio := "[0:a]"
-	for i := 1; i < n; i++ {
-		outputLabel := fmt.Sprintf("[a%d]", i)
-		if i == n-1 {
-			outputLabel = "[aout]"
-		}
-		audioFilterParts = append(audioFilterParts,
-			fmt.Sprintf("%s[%d:a]acrossfade=d=%.2f%s",
-				lastAudio, i, duration, outputLabel))
-		lastAudio = outputLabel
+	args := []string{"-autorotate"}
+	if video.InPoint > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", video.InPoint.Seconds()))
 	}
-
-	filter := strings.Join(filterParts, ";") + ";" + strings.Join(audioFilterParts, ";")
-
-	return []string{"-filter_complex", filter, "-map", "[vout]", "-map", "[aout]"}
+	if video.IsTrimmed() && video.TrimmedDuration() > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", video.TrimmedDuration().Seconds()))
+	}
+	return append(args, "-i", video.Path)
 }
 
-func buildFadeFilter(videos []*Video, duration float64) []string {
+// buildTransitionFilter joins the clips one boundary at a time, so each
+// boundary can have its own transition: a cut concatenates, a fade fades
+// the program so far out and the next clip in before concatenating, and
+// the xfade transitions overlap the next clip with the end of the program
+// so far. xfade needs matching timebases and frame rates on both inputs,
+// which the concat filter doesn't keep, so every clip and every concat
+// output is conformed to frameRate. The graph ends in [vout] and [aclips].
+func buildTransitionFilter(videos []*Video, inputs streamInputs, boundaries []Transition, frameRate float64) []string {
 	n := len(videos)
-	if n < 1 {
+	if n < 2 {
 		return nil
 	}
 
-	var filterParts []string
-	var audioFilterParts []string
+	var videoParts, audioParts []string
 
-	// Add fade out at end of each video (except last) and fade in at start (except first)
-	for i := 0; i < n; i++ {
-		videoDur := videos[i].Duration.Seconds()
-		fadeOutStart := videoDur - duration
+	conform := fmt.Sprintf("settb=AVTB,fps=%.3f", frameRate)
+	clipVideo := make([]string, n)
+	for i := range videos {
+		clipVideo[i] = fmt.Sprintf("[tv%d]", i)
+		videoParts = append(videoParts, fmt.Sprintf("%s%s%s", inputs.video[i], conform, clipVideo[i]))
+	}
 
-		var vfilter string
-		var afilter string
+	lastVideo, lastAudio := clipVideo[0], inputs.audio[0]
+	length := videos[0].TrimmedDuration().Seconds()
 
-		if i == 0 {
-			// First video: fade out only
-			vfilter = fmt.Sprintf("[%d:v]fade=t=out:st=%.2f:d=%.2f[v%d]", i, fadeOutStart, duration, i)
-			afilter = fmt.Sprintf("[%d:a]afade=t=out:st=%.2f:d=%.2f[a%d]", i, fadeOutStart, duration, i)
-		} else if i == n-1 {
-			// Last video: fade in only
-			vfilter = fmt.Sprintf("[%d:v]fade=t=in:st=0:d=%.2f[v%d]", i, duration, i)
-			afilter = fmt.Sprintf("[%d:a]afade=t=in:st=0:d=%.2f[a%d]", i, duration, i)
-		} else {
-			// Middle videos: both fade in and fade out
-			vfilter = fmt.Sprintf("[%d:v]fade=t=in:st=0:d=%.2f,fade=t=out:st=%.2f:d=%.2f[v%d]", i, duration, fadeOutStart, duration, i)
-			afilter = fmt.Sprintf("[%d:a]afade=t=in:st=0:d=%.2f,afade=t=out:st=%.2f:d=%.2f[a%d]", i, duration, fadeOutStart, duration, i)
+	for i := 1; i < n; i++ {
+		t := boundaries[i-1]
+		nextVideo, nextAudio := clipVideo[i], inputs.audio[i]
+		clipLength := videos[i].TrimmedDuration().Seconds()
+
+		outVideo, outAudio := fmt.Sprintf("[v%d]", i), fmt.Sprintf("[a%d]", i)
+		if i == n-1 {
+			outVideo, outAudio = "[vout]", "[aclips]"
 		}
 
-		filterParts = append(filterParts, vfilter)
-		audioFilterParts = append(audioFilterParts, afilter)
-	}
+		switch {
+		case t.Type.Overlaps():
+			videoParts = append(videoParts,
+				fmt.Sprintf("%s%sxfade=transition=%s:duration=%.3f:offset=%.3f%s",
+					lastVideo, nextVideo, t.Type.info().xfade, t.Duration, length-t.Duration, outVideo))
+			audioParts = append(audioParts,
+				fmt.Sprintf("%s%sacrossfade=d=%.3f%s", lastAudio, nextAudio, t.Duration, outAudio))
+			length += clipLength - t.Duration
+			lastVideo, lastAudio = outVideo, outAudio
+			continue
+
+		case t.Type == TransitionFade:
+			fadeOut := length - t.Duration
+			videoParts = append(videoParts,
+				fmt.Sprintf("%sfade=t=out:st=%.3f:d=%.3f[fo%d]", lastVideo, fadeOut, t.Duration, i),
+				fmt.Sprintf("%sfade=t=in:st=0:d=%.3f[fi%d]", nextVideo, t.Duration, i))
+			audioParts = append(audioParts,
+				fmt.Sprintf("%safade=t=out:st=%.3f:d=%.3f[afo%d]", lastAudio, fadeOut, t.Duration, i),
+				fmt.Sprintf("%safade=t=in:st=0:d=%.3f[afi%d]", nextAudio, t.Duration, i))
+			lastVideo, lastAudio = fmt.Sprintf("[fo%d]", i), fmt.Sprintf("[afo%d]", i)
+			nextVideo, nextAudio = fmt.Sprintf("[fi%d]", i), fmt.Sprintf("[afi%d]", i)
+		}
 
-	// Concat all faded segments
-	var concatInputs string
-	for i := 0; i < n; i++ {
-		concatInputs += fmt.Sprintf("[v%d][a%d]", i, i)
+		// Cut, or the concatenation after a fade
+		videoParts = append(videoParts,
+			fmt.Sprintf("%s%s%s%sconcat=n=2:v=1:a=1[cv%d]%s;[cv%d]%s%s",
+				lastVideo, lastAudio, nextVideo, nextAudio, i, outAudio, i, conform, outVideo))
+		length += clipLength
+		lastVideo, lastAudio = outVideo, outAudio
 	}
-	concatFilter := fmt.Sprintf("%sconcat=n=%d:v=1:a=1[vout][aout]", concatInputs, n)
-
-	filter := strings.Join(filterParts, ";") + ";" + strings.Join(audioFilterParts, ";") + ";" + concatFilter
 
-	return []string{"-filter_complex", filter, "-map", "[vout]", "-map", "[aout]"}
+	return slices.Concat(inputs.filters, videoParts, audioParts)
 }
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"image"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// transitionPreview plays a looping preview of the chosen transition in the
+// export dialog, rendered in the background from two adjacent clips.
+type transitionPreview struct {
+	a, b      *Video
+	image     *canvas.Image
+	label     *widget.Label
+	content   fyne.CanvasObject
+	cancel    context.CancelFunc
+	animation *fyne.Animation
+}
+
+func newTransitionPreview(a, b *Video) *transitionPreview {
+	p := &transitionPreview{
+		a:     a,
+		b:     b,
+		image: canvas.NewImageFromImage(nil),
+		label: widget.NewLabel(""),
+	}
+	p.image.SetMinSize(fyne.NewSize(previewWidth, previewHeight))
+	p.image.FillMode = canvas.ImageFillContain
+	p.content = container.NewHBox(p.image, p.label)
+	return p
+}
+
+// render replaces the preview with one of transition, stopping any render
+// or animation still running.
+func (p *transitionPreview) render(transition TransitionType, duration float64) {
+	p.stop()
+	p.image.Image = nil
+	p.image.Refresh()
+
+	switch {
+	case p.a == nil:
+		p.label.SetText("Add two loaded clips\nto preview transitions")
+		return
+	case transition == TransitionNone:
+		p.label.SetText("Cut")
+		return
+	}
+	p.label.SetText("Rendering preview...")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	go func() {
+		frames, err := RenderTransitionPreview(ctx, p.a, p.b, transition, duration)
+		if ctx.Err() != nil {
+			return
+		}
+
+		fyne.Do(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Transition preview failed: %v", err)
+				p.label.SetText("Preview not available")
+				return
+			}
+			p.label.SetText(p.a.Name + "\n→ " + p.b.Name)
+			p.play(frames, duration)
+		})
+	}()
+}
+
+// play loops frames over the transition duration.
+func (p *transitionPreview) play(frames []image.Image, duration float64) {
+	length := time.Duration(min(max(duration, 0.5), 3) * float64(time.Second))
+	p.animation = fyne.NewAnimation(length, func(f float32) {
+		i := min(int(f*float32(len(frames))), len(frames)-1)
+		if p.image.Image != frames[i] {
+			p.image.Image = frames[i]
+			p.image.Refresh()
+		}
+	})
+	p.animation.Curve = fyne.AnimationLinear
+	p.animation.RepeatCount = fyne.AnimationRepeatForever
+	p.animation.Start()
+}
+
+func (p *transitionPreview) stop() {
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	if p.animation != nil {
+		p.animation.Stop()
+		p.animation = nil
+	}
+}
@@ -0,0 +1,406 @@
+package app
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FCPXML is Final Cut Pro's interchange format, which DaVinci Resolve and
+// Premiere read too. Clips are asset-clips on the primary storyline (the
+// spine) and overlapping transitions become cross dissolves.
+//
+// In FCPXML the clips on either side of a transition butt up against each
+// other and the transition is centred on the edit, running over the media
+// beyond it. So a clip is shortened by half of each transition around it,
+// and the transition starts half of its length before the edit.
+
+const fcpxmlVersion = "1.9"
+
+// Effects the export refers to, as identified by Final Cut Pro
+const (
+	fcpxCrossDissolve = "FxPlug:4731E73A-8DAC-4113-9A30-AE85B1761265"
+	fcpxAudioFade     = "FFAudioTransition"
+	fcpxBasicTitle    = ".../Titles.localized/Bumper:Opener.localized/Basic Title.localized/Basic Title.moti"
+)
+
+type fcpxDocument struct {
+	XMLName   xml.Name      `xml:"fcpxml"`
+	Version   string        `xml:"version,attr"`
+	Resources fcpxResources `xml:"resources"`
+	Event     fcpxEvent     `xml:"library>event"`
+}
+
+type fcpxResources struct {
+	Formats []fcpxFormat `xml:"format"`
+	Assets  []fcpxAsset  `xml:"asset"`
+	Effects []fcpxEffect `xml:"effect"`
+}
+
+type fcpxFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxAsset struct {
+	ID            string `xml:"id,attr"`
+	Name          string `xml:"name,attr"`
+	Start         string `xml:"start,attr"`
+	Duration      string `xml:"duration,attr"`
+	HasVideo      string `xml:"hasVideo,attr"`
+	HasAudio      string `xml:"hasAudio,attr,omitempty"`
+	Format        string `xml:"format,attr"`
+	AudioSources  string `xml:"audioSources,attr,omitempty"`
+	AudioChannels int    `xml:"audioChannels,attr,omitempty"`
+	AudioRate     int    `xml:"audioRate,attr,omitempty"`
+	MediaRep      struct {
+		Kind string `xml:"kind,attr"`
+		Src  string `xml:"src,attr"`
+	} `xml:"media-rep"`
+}
+
+type fcpxEffect struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+	UID  string `xml:"uid,attr"`
+}
+
+type fcpxEvent struct {
+	Name    string `xml:"name,attr"`
+	Project struct {
+		Name     string       `xml:"name,attr"`
+		Sequence fcpxSequence `xml:"sequence"`
+	} `xml:"project"`
+}
+
+type fcpxSequence struct {
+	Format      string `xml:"format,attr"`
+	Duration    string `xml:"duration,attr"`
+	TCStart     string `xml:"tcStart,attr"`
+	TCFormat    string `xml:"tcFormat,attr"`
+	AudioLayout string `xml:"audioLayout,attr"`
+	AudioRate   string `xml:"audioRate,attr"`
+	Spine       struct {
+		Items []any // asset-clips, transitions and titles in order
+	} `xml:"spine"`
+}
+
+type fcpxAssetClip struct {
+	XMLName  xml.Name `xml:"asset-clip"`
+	Ref      string   `xml:"ref,attr"`
+	Offset   string   `xml:"offset,attr"`
+	Name     string   `xml:"name,attr"`
+	Start    string   `xml:"start,attr"`
+	Duration string   `xml:"duration,attr"`
+	Format   string   `xml:"format,attr"`
+	TCFormat string   `xml:"tcFormat,attr"`
+}
+
+type fcpxTransition struct {
+	XMLName     xml.Name    `xml:"transition"`
+	Name        string      `xml:"name,attr"`
+	Offset      string      `xml:"offset,attr"`
+	Duration    string      `xml:"duration,attr"`
+	FilterVideo fcpxFilter  `xml:"filter-video"`
+	FilterAudio *fcpxFilter `xml:"filter-audio,omitempty"`
+}
+
+type fcpxFilter struct {
+	Ref  string `xml:"ref,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type fcpxTitle struct {
+	XMLName  xml.Name `xml:"title"`
+	Ref      string   `xml:"ref,attr"`
+	Offset   string   `xml:"offset,attr"`
+	Name     string   `xml:"name,attr"`
+	Start    string   `xml:"start,attr"`
+	Duration string   `xml:"duration,attr"`
+	Text     struct {
+		Style struct {
+			Ref  string `xml:"ref,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"text-style"`
+	} `xml:"text"`
+	StyleDef struct {
+		ID    string `xml:"id,attr"`
+		Style struct {
+			Font      string `xml:"font,attr"`
+			FontSize  int    `xml:"fontSize,attr"`
+			FontColor string `xml:"fontColor,attr"`
+			Alignment string `xml:"alignment,attr"`
+		} `xml:"text-style"`
+	} `xml:"text-style-def"`
+}
+
+// fcpxTimebase writes times as whole frames of a frame duration, which
+// FCPXML expects them to be. NTSC rates such as 29.97 have frames of
+// 1001/30000s.
+type fcpxTimebase struct {
+	num, den int
+}
+
+func newFCPXTimebase(frameRate float64) fcpxTimebase {
+	for _, rate := range []int{24, 30, 60, 120} {
+		if math.Abs(frameRate-float64(rate)*1000/1001) < 0.01 {
+			return fcpxTimebase{num: 1001, den: rate * 1000}
+		}
+	}
+	rate := int(math.Round(frameRate))
+	if rate <= 0 {
+		rate = defaultFrameRate
+	}
+	return fcpxTimebase{num: 1, den: rate}
+}
+
+func (tb fcpxTimebase) frames(d time.Duration) int {
+	return int(math.Round(d.Seconds() * float64(tb.den) / float64(tb.num)))
+}
+
+func (tb fcpxTimebase) duration(frames int) time.Duration {
+	return time.Duration(frames) * time.Second * time.Duration(tb.num) / time.Duration(tb.den)
+}
+
+// time formats a number of frames as a rational time, e.g. "1001/30000s".
+func (tb fcpxTimebase) time(frames int) string {
+	num, den := frames*tb.num, tb.den
+	if num == 0 {
+		return "0s"
+	}
+	d := gcd(num, den)
+	num, den = num/d, den/d
+	if den == 1 {
+		return strconv.Itoa(num) + "s"
+	}
+	return fmt.Sprintf("%d/%ds", num, den)
+}
+
+func (tb fcpxTimebase) frameDuration() string {
+	return fmt.Sprintf("%d/%ds", tb.num, tb.den)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return max(a, -a)
+}
+
+// fcpxColor converts an ffmpeg color to FCPXML's "r g b a", for the
+// colors title cards are usually given. Anything else is white.
+func fcpxColor(color string) string {
+	hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(color), "#"), "0x")
+	switch {
+	case hex == "black":
+		return "0 0 0 1"
+	case len(hex) == 6:
+		if rgb, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return fmt.Sprintf("%.3g %.3g %.3g 1",
+				float64(rgb>>16&0xff)/255, float64(rgb>>8&0xff)/255, float64(rgb&0xff)/255)
+		}
+	}
+	return "1 1 1 1"
+}
+
+// fcpxFileURL returns the file URL FCPXML locates media by.
+func fcpxFileURL(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// A Windows drive letter
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// FormatFCPXML returns videos as an FCPXML document with a single project
+// named title. The sequence takes the export canvas where one is set and
+// the first clip's size and frame rate otherwise. Fades through black,
+// which don't overlap the clips, are written as cuts. Every clip needs its
+// media information, so all clips must be loaded.
+func FormatFCPXML(title string, videos []*Video, options ExportOptions) ([]byte, error) {
+	if len(videos) == 0 {
+		return nil, fmt.Errorf("no videos to export")
+	}
+	for _, video := range videos {
+		switch video.Status {
+		case VideoProbing:
+			return nil, fmt.Errorf("%s is still loading", video.Name)
+		case VideoOffline:
+			return nil, fmt.Errorf("%s is offline, relink it first", video.Name)
+		case VideoFailed:
+			return nil, fmt.Errorf("%s could not be loaded: %v", video.Name, video.LoadErr)
+		}
+	}
+
+	report, err := AnalyzeCompatibility(videos)
+	if err != nil {
+		return nil, err
+	}
+	canvas := report.ResolveCanvas(options.Canvas)
+	seq := newFCPXTimebase(canvas.FrameRate)
+
+	doc := fcpxDocument{Version: fcpxmlVersion}
+	var nextID int
+	newID := func() string {
+		nextID++
+		return fmt.Sprintf("r%d", nextID)
+	}
+
+	// Clips of the same size and frame rate share a format
+	formatIDs := make(map[string]string)
+	format := func(width, height int, tb fcpxTimebase) string {
+		key := fmt.Sprintf("%dx%d@%s", width, height, tb.frameDuration())
+		if id, ok := formatIDs[key]; ok {
+			return id
+		}
+		id := newID()
+		doc.Resources.Formats = append(doc.Resources.Formats, fcpxFormat{ID: id, FrameDuration: tb.frameDuration(), Width: width, Height: height})
+		formatIDs[key] = id
+		return id
+	}
+	sequenceFormat := format(canvas.Width, canvas.Height, seq)
+
+	assetIDs := make(map[string]string)
+	effectIDs := make(map[string]string)
+	effect := func(name, uid string) string {
+		if id, ok := effectIDs[uid]; ok {
+			return id
+		}
+		id := newID()
+		doc.Resources.Effects = append(doc.Resources.Effects, fcpxEffect{ID: id, Name: name, UID: uid})
+		effectIDs[uid] = id
+		return id
+	}
+
+	boundaries := boundaryTransitions(videos, options)
+	overlap := func(i int) int {
+		if i < 0 || i >= len(boundaries) {
+			return 0
+		}
+		return seq.frames(boundaries[i].Overlap())
+	}
+
+	sequence := fcpxSequence{
+		Format:      sequenceFormat,
+		TCStart:     "0s",
+		TCFormat:    "NDF",
+		AudioLayout: "stereo",
+		AudioRate:   "48k",
+	}
+	offset := 0
+	for i, video := range videos {
+		// Half of each transition runs over this clip's media
+		head, tail := overlap(i-1)-overlap(i-1)/2, overlap(i)/2
+		length := seq.frames(video.TrimmedDuration()) - head - tail
+		if length <= 0 {
+			return nil, fmt.Errorf("%s is too short for its transitions", video.Name)
+		}
+
+		if i > 0 && overlap(i-1) > 0 {
+			transition := fcpxTransition{
+				Name:        "Cross Dissolve",
+				Offset:      seq.time(offset - head),
+				Duration:    seq.time(overlap(i - 1)),
+				FilterVideo: fcpxFilter{Ref: effect("Cross Dissolve", fcpxCrossDissolve), Name: "Cross Dissolve"},
+			}
+			if video.HasAudio || videos[i-1].HasAudio {
+				transition.FilterAudio = &fcpxFilter{Ref: effect("Audio Crossfade", fcpxAudioFade), Name: "Audio Crossfade"}
+			}
+			sequence.Spine.Items = append(sequence.Spine.Items, transition)
+		}
+
+		if video.IsTitle() {
+			card := video.Title
+			t := fcpxTitle{
+				Ref:      effect("Basic Title", fcpxBasicTitle),
+				Offset:   seq.time(offset),
+				Name:     video.Name,
+				Start:    seq.time(head),
+				Duration: seq.time(length),
+			}
+			styleID := fmt.Sprintf("ts%d", i+1)
+			t.Text.Style.Ref = styleID
+			t.Text.Style.Text = card.Text
+			t.StyleDef.ID = styleID
+			t.StyleDef.Style.Font = "Helvetica"
+			if card.Font != "" && !isFontFile(card.Font) {
+				t.StyleDef.Style.Font = card.Font
+			}
+			t.StyleDef.Style.FontSize = card.FontSize * canvas.Height / referenceHeight
+			t.StyleDef.Style.FontColor = fcpxColor(card.Color)
+			t.StyleDef.Style.Alignment = "center"
+			sequence.Spine.Items = append(sequence.Spine.Items, t)
+			offset += length
+			continue
+		}
+
+		clip := newFCPXTimebase(video.Media.FrameRate)
+		width, height := video.Media.DisplaySize()
+		clipFormat := format(width, height, clip)
+
+		assetID, ok := assetIDs[video.Path]
+		if !ok {
+			assetID = newID()
+			assetIDs[video.Path] = assetID
+			asset := fcpxAsset{
+				ID:       assetID,
+				Name:     video.Name,
+				Start:    "0s",
+				Duration: clip.time(clip.frames(video.Duration)),
+				HasVideo: "1",
+				Format:   clipFormat,
+			}
+			if video.Media.HasAudio() {
+				asset.HasAudio = "1"
+				asset.AudioSources = "1"
+				asset.AudioChannels = video.Media.Audio[0].Channels
+				asset.AudioRate = video.Media.Audio[0].SampleRate
+			}
+			asset.MediaRep.Kind = "original-media"
+			asset.MediaRep.Src = fcpxFileURL(video.Path)
+			doc.Resources.Assets = append(doc.Resources.Assets, asset)
+		}
+
+		// The start is in the clip's own frames
+		start := video.InPoint + seq.duration(head)
+		sequence.Spine.Items = append(sequence.Spine.Items, fcpxAssetClip{
+			Ref:      assetID,
+			Offset:   seq.time(offset),
+			Name:     video.Name,
+			Start:    clip.time(clip.frames(start)),
+			Duration: seq.time(length),
+			Format:   clipFormat,
+			TCFormat: "NDF",
+		})
+		offset += length
+	}
+	sequence.Duration = seq.time(offset)
+
+	doc.Event.Name = title
+	doc.Event.Project.Name = title
+	doc.Event.Project.Sequence = sequence
+
+	data, err := xml.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header+"<!DOCTYPE fcpxml>\n\n"), append(data, '\n')...), nil
+}
+
+// ExportFCPXML writes videos to path as an FCPXML document.
+func ExportFCPXML(path, title string, videos []*Video, options ExportOptions) error {
+	data, err := FormatFCPXML(title, videos, options)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runFFmpeg runs ffmpeg with machine-readable progress on stdout and
+// reports percentage, elapsed time and ETA against the expected output
+// duration. On failure the returned error includes ffmpeg's log output.
+// Cancelling ctx kills ffmpeg and any processes it spawned.
+func runFFmpeg(ctx context.Context, args []string, expected time.Duration, status string, progress chan<- ExportProgress) error {
+	_, err := runFFmpegLog(ctx, args, expected, status, progress)
+	return err
+}
+
+// runFFmpegLog is runFFmpeg for passes whose results are printed to the
+// log, such as loudness measurements. It returns ffmpeg's log output.
+func runFFmpegLog(ctx context.Context, args []string, expected time.Duration, status string, progress chan<- ExportProgress) (string, error) {
+	args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	progress <- ExportProgress{Status: status}
+
+	var outTime time.Duration
+	var speed float64
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "out_time_us":
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				outTime = time.Duration(us) * time.Microsecond
+			}
+		case "out_time":
+			if d, err := ParseTimecode(value); err == nil && outTime == 0 {
+				outTime = d
+			}
+		case "speed":
+			if s, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+				speed = s
+			}
+		case "progress":
+			// Each block of key=value pairs ends with a progress line
+			progress <- newExportProgress(status, outTime, expected, speed, time.Since(start))
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("ffmpeg error: %w\n%s", err, stderr.String())
+	}
+	return stderr.String(), nil
+}
+
+func newExportProgress(status string, outTime, expected time.Duration, speed float64, elapsed time.Duration) ExportProgress {
+	p := ExportProgress{Status: status, Elapsed: elapsed}
+	if expected <= 0 {
+		return p
+	}
+
+	p.Percent = 100 * outTime.Seconds() / expected.Seconds()
+	if p.Percent > 100 {
+		p.Percent = 100
+	}
+
+	remaining := expected - outTime
+	if remaining < 0 {
+		remaining = 0
+	}
+	if speed > 0 {
+		p.ETA = time.Duration(float64(remaining) / speed)
+	} else if outTime > 0 {
+		p.ETA = time.Duration(float64(elapsed) * remaining.Seconds() / outTime.Seconds())
+	}
+
+	return p
+}
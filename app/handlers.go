@@ -1,12 +1,16 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -29,6 +33,10 @@ func isVideoFile(path string) bool {
 type Handlers struct {
 	state  *State
 	window fyne.Window
+
+	importMu     sync.Mutex
+	importCtx    context.Context
+	importCancel context.CancelFunc
 }
 
 func NewHandlers(state *State, window fyne.Window) *Handlers {
@@ -46,10 +54,21 @@ func (h *Handlers) OnNew() {
 	dialog.ShowConfirm("New Project", "Start a new project? All unsaved changes will be lost.", func(ok bool) {
 		if ok {
 			h.state.Clear()
+			h.state.SetSettings(ProjectSettings{Export: ExportOptions{TransitionDuration: 1.0}})
+			h.updateTitle()
 		}
 	}, h.window)
 }
 
+// updateTitle shows the project name in the window title.
+func (h *Handlers) updateTitle() {
+	title := "Video Arranger"
+	if name := h.state.Settings().Name; name != "" {
+		title = name + " - " + title
+	}
+	h.window.SetTitle(title)
+}
+
 func (h *Handlers) OnAddVideos() {
 	log.Println("Opening file dialog...")
 	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
@@ -123,29 +142,124 @@ func (h *Handlers) OnAddFolder() {
 	fd.Show()
 }
 
+// AddVideosWithProgress adds a placeholder row for each path right away and
+// loads the videos in the background while the list stays usable.
 func (h *Handlers) AddVideosWithProgress(paths []string) {
 	if len(paths) == 0 {
 		return
 	}
 
-	progressBar := widget.NewProgressBar()
-	progressLabel := widget.NewLabel("Loading videos...")
-	content := container.NewVBox(progressLabel, progressBar)
+	videos := make([]*Video, len(paths))
+	for i, path := range paths {
+		videos[i] = NewPlaceholder(path)
+	}
 
-	progressDialog := dialog.NewCustomWithoutButtons("Loading Videos", content, h.window)
-	progressDialog.Show()
+	h.loadPlaceholders(videos, func(results []ImportResult) {
+		if summary := ImportSummary(results); summary != "" {
+			dialog.ShowInformation("Loading Videos", summary, h.window)
+		}
+	})
+}
 
-	go func() {
-		for i, path := range paths {
-			progress := float64(i+1) / float64(len(paths))
-			progressBar.SetValue(progress)
-			progressLabel.SetText(fmt.Sprintf("Loading video %d of %d...", i+1, len(paths)))
+// importConcurrencyKey is the preference holding the number of files
+// probed in parallel, set in the settings dialog. Zero means one per CPU,
+// as with the CLI's --jobs.
+const importConcurrencyKey = "importConcurrency"
 
-			log.Printf("Adding video: %s", path)
-			h.state.AddVideo(path)
+// importConcurrencyChoices are the values offered in the settings dialog.
+var importConcurrencyChoices = []int{0, 1, 2, 4, 8, 16}
+
+// importConcurrency is the number of files probed in parallel.
+func (h *Handlers) importConcurrency() int {
+	return fyne.CurrentApp().Preferences().IntWithFallback(importConcurrencyKey, 0)
+}
+
+func concurrencyName(n int) string {
+	if n <= 0 {
+		return "Auto (one per CPU)"
+	}
+	return strconv.Itoa(n)
+}
+
+// OnSettings edits the app-wide preferences.
+func (h *Handlers) OnSettings() {
+	var names []string
+	for _, n := range importConcurrencyChoices {
+		names = append(names, concurrencyName(n))
+	}
+	concurrencySelect := widget.NewSelect(names, nil)
+	current := concurrencyName(h.importConcurrency())
+	if !slices.Contains(names, current) {
+		// Keep a value set some other way selectable
+		concurrencySelect.Options = append(concurrencySelect.Options, current)
+	}
+	concurrencySelect.SetSelected(current)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Parallel imports", concurrencySelect),
+	)
+
+	dialog.ShowCustomConfirm("Settings", "OK", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		n, err := strconv.Atoi(concurrencySelect.Selected)
+		if err != nil {
+			n = 0
 		}
-		progressDialog.Hide()
-	}()
+		fyne.CurrentApp().Preferences().SetInt(importConcurrencyKey, n)
+	}, h.window)
+}
+
+// loadPlaceholders adds videos to the list and loads them in the
+// background. All imports running at once share one context so
+// OnCancelImport stops them together. done is not called if the user
+// cancels.
+func (h *Handlers) loadPlaceholders(videos []*Video, done func(results []ImportResult)) {
+	ctx := h.importContext()
+	h.state.AddPlaceholders(ctx, videos, h.importConcurrency(), h.importDone(ctx, done))
+}
+
+// importContext returns the context shared by all running imports.
+func (h *Handlers) importContext() context.Context {
+	h.importMu.Lock()
+	defer h.importMu.Unlock()
+
+	if h.importCtx == nil {
+		h.importCtx, h.importCancel = context.WithCancel(context.Background())
+	}
+	return h.importCtx
+}
+
+// importDone logs failed files and passes the results on to done unless
+// the import was cancelled. Imports finish on a worker goroutine, so done
+// runs on the UI thread where it can show dialogs.
+func (h *Handlers) importDone(ctx context.Context, done func(results []ImportResult)) func(results []ImportResult) {
+	return func(results []ImportResult) {
+		for _, result := range results {
+			if result.Err != nil {
+				log.Printf("Failed to load video %s: %v", result.Path, result.Err)
+			}
+		}
+
+		if ctx.Err() != nil {
+			log.Printf("Import of %d videos cancelled", len(results))
+			return
+		}
+		fyne.Do(func() { done(results) })
+	}
+}
+
+// OnCancelImport stops loading videos in the background. Videos that
+// haven't loaded yet stay in the list, marked as failed.
+func (h *Handlers) OnCancelImport() {
+	h.importMu.Lock()
+	defer h.importMu.Unlock()
+
+	if h.importCancel != nil {
+		h.importCancel()
+		h.importCtx, h.importCancel = nil, nil
+	}
 }
 
 func (h *Handlers) OnRemove() {
@@ -160,6 +274,78 @@ func (h *Handlers) OnMoveDown() {
 	h.state.MoveDown()
 }
 
+func (h *Handlers) OnTrim(in, out time.Duration) {
+	if err := h.state.SetTrim(h.state.GetSelected(), in, out); err != nil {
+		dialog.ShowError(err, h.window)
+	}
+}
+
+// OnChapterTitle sets the chapter title of the selected clip.
+func (h *Handlers) OnChapterTitle(title string) {
+	h.state.SetChapterTitle(h.state.GetSelected(), strings.TrimSpace(title))
+}
+
+// OnEditTransition edits the transition at the boundary after the clip at
+// index.
+func (h *Handlers) OnEditTransition(index int) {
+	videos := h.state.GetVideos()
+	if index < 0 || index >= len(videos)-1 {
+		return
+	}
+	video := videos[index]
+	defaults := h.state.Settings().Export
+
+	defaultName := "Default (" + BoundaryTransition(&Video{}, defaults).String() + ")"
+	typeSelect := widget.NewSelect(append([]string{defaultName}, TransitionNames()...), nil)
+	durationEntry := widget.NewEntry()
+	durationEntry.SetPlaceHolder("Default")
+
+	if video.TransitionOut == nil {
+		typeSelect.SetSelected(defaultName)
+	} else {
+		typeSelect.SetSelected(video.TransitionOut.Type.String())
+		if video.TransitionOut.Duration > 0 {
+			durationEntry.SetText(strconv.FormatFloat(video.TransitionOut.Duration, 'f', -1, 64))
+		}
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Transition", typeSelect),
+		widget.NewFormItem("Duration (sec)", durationEntry),
+	)
+
+	title := fmt.Sprintf("Transition after %s", video.Name)
+	dialog.ShowCustomConfirm(title, "OK", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if typeSelect.Selected == defaultName {
+			h.state.SetTransition(index, nil)
+			return
+		}
+
+		transition := &Transition{}
+		transition.Type, _ = ParseTransitionType(typeSelect.Selected)
+		if text := strings.TrimSpace(durationEntry.Text); text != "" {
+			dur, err := strconv.ParseFloat(text, 64)
+			if err != nil || dur <= 0 {
+				dialog.ShowError(fmt.Errorf("invalid duration %q", text), h.window)
+				return
+			}
+			transition.Duration = dur
+		}
+		h.state.SetTransition(index, transition)
+	}, h.window)
+}
+
+func (h *Handlers) OnUndo() {
+	h.state.Undo()
+}
+
+func (h *Handlers) OnRedo() {
+	h.state.Redo()
+}
+
 func (h *Handlers) OnClear() {
 	if h.state.Count() == 0 {
 		return
@@ -172,6 +358,24 @@ func (h *Handlers) OnClear() {
 	}, h.window)
 }
 
+func (h *Handlers) OnClearCache() {
+	cache := DefaultMediaCache()
+	if cache == nil {
+		dialog.ShowInformation("Clear Cache", "The media cache is not available.", h.window)
+		return
+	}
+
+	message := fmt.Sprintf("Delete all cached thumbnails and metadata (%s)?\nThey will be recreated the next time videos are loaded.", FormatBytes(cache.Size()))
+	dialog.ShowConfirm("Clear Cache", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := cache.Clear(); err != nil {
+			dialog.ShowError(err, h.window)
+		}
+	}, h.window)
+}
+
 func (h *Handlers) OnExport() {
 	if h.state.Count() == 0 {
 		dialog.ShowInformation("Export", "No videos to export. Add some videos first.", h.window)
@@ -182,32 +386,98 @@ func (h *Handlers) OnExport() {
 }
 
 func (h *Handlers) showExportOptions() {
-	transitionSelect := widget.NewSelect([]string{"None", "Fade", "Crossfade"}, nil)
-	transitionSelect.SetSelected("None")
+	// Start from the options last used in this project
+	settings := h.state.Settings()
+	saved := settings.Export
+
+	transitionSelect := widget.NewSelect(TransitionNames(), nil)
+	transitionSelect.SetSelected(saved.Transition.String())
 
 	durationEntry := widget.NewEntry()
-	durationEntry.SetText("1.0")
+	durationEntry.SetText(strconv.FormatFloat(saved.TransitionDuration, 'f', -1, 64))
+	if saved.TransitionDuration <= 0 {
+		durationEntry.SetText("1.0")
+	}
+
+	preview := newTransitionPreview(h.previewClips())
+	update := func() {
+		transition, _ := ParseTransitionType(transitionSelect.Selected)
+		dur, err := strconv.ParseFloat(durationEntry.Text, 64)
+		if err != nil || dur <= 0 {
+			dur = 1.0
+		}
+		preview.render(transition, dur)
+	}
+	transitionSelect.OnChanged = func(string) { update() }
+	durationEntry.OnChanged = func(string) { update() }
+	update()
+
+	loudness := saved.Loudness
+	if !loudness.Enabled() {
+		loudness = DefaultLoudness()
+	}
+	var scopeNames []string
+	for _, scope := range LoudnessScopes {
+		scopeNames = append(scopeNames, scope.String())
+	}
+	targetEntry := widget.NewEntry()
+	targetEntry.SetText(strconv.FormatFloat(loudness.Target, 'f', -1, 64))
+	truePeakEntry := widget.NewEntry()
+	truePeakEntry.SetText(strconv.FormatFloat(loudness.TruePeak, 'f', -1, 64))
+	rangeEntry := widget.NewEntry()
+	rangeEntry.SetText(strconv.FormatFloat(loudness.Range, 'f', -1, 64))
+	loudnessSelect := widget.NewSelect(scopeNames, func(name string) {
+		for _, entry := range []*widget.Entry{targetEntry, truePeakEntry, rangeEntry} {
+			if name == LoudnessOff.String() {
+				entry.Disable()
+			} else {
+				entry.Enable()
+			}
+		}
+	})
+	loudnessSelect.SetSelected(saved.Loudness.Scope.String())
+
+	var subtitleNames []string
+	for _, mode := range SubtitleModes {
+		subtitleNames = append(subtitleNames, mode.String())
+	}
+	subtitleSelect := widget.NewSelect(subtitleNames, nil)
+	subtitleSelect.SetSelected(saved.Subtitles.String())
+
+	canvasSelect := widget.NewSelect([]string{"Auto", "3840x2160", "1920x1080", "1280x720", "1080x1920"}, nil)
+	canvasSelect.SetSelected("Auto")
+	if saved.Canvas.Width > 0 && saved.Canvas.Height > 0 {
+		canvasSelect.SetSelected(fmt.Sprintf("%dx%d", saved.Canvas.Width, saved.Canvas.Height))
+	}
+
+	fpsSelect := widget.NewSelect([]string{"Auto", "24", "25", "30", "50", "60"}, nil)
+	fpsSelect.SetSelected("Auto")
+	if saved.Canvas.FrameRate > 0 {
+		fpsSelect.SetSelected(strconv.FormatFloat(saved.Canvas.FrameRate, 'f', -1, 64))
+	}
 
 	form := widget.NewForm(
 		widget.NewFormItem("Transition", transitionSelect),
+		widget.NewFormItem("Preview", preview.content),
 		widget.NewFormItem("Duration (sec)", durationEntry),
+		widget.NewFormItem("Canvas", canvasSelect),
+		widget.NewFormItem("Frame rate", fpsSelect),
+		widget.NewFormItem("Loudness", loudnessSelect),
+		widget.NewFormItem("Target (LUFS)", targetEntry),
+		widget.NewFormItem("True peak (dBTP)", truePeakEntry),
+		widget.NewFormItem("Range (LU)", rangeEntry),
+		widget.NewFormItem("Subtitles", subtitleSelect),
 	)
+	form.Append("", widget.NewLabel("Transitions set between clips in the list\noverride the default transition.\nCanvas and frame rate apply when clips\nhave to be re-encoded to match.\nSubtitles come from .srt or .vtt files next\nto the clips, or from their subtitle streams."))
 
 	dialog.ShowCustomConfirm("Export Options", "Next", "Cancel", form, func(confirmed bool) {
+		preview.stop()
 		if !confirmed {
 			return
 		}
 
-		options := ExportOptions{}
-
-		switch transitionSelect.Selected {
-		case "Fade":
-			options.Transition = TransitionFade
-		case "Crossfade":
-			options.Transition = TransitionCrossfade
-		default:
-			options.Transition = TransitionNone
-		}
+		options := ExportOptions{Music: saved.Music}
+		options.Transition, _ = ParseTransitionType(transitionSelect.Selected)
 
 		if dur, err := strconv.ParseFloat(durationEntry.Text, 64); err == nil && dur > 0 {
 			options.TransitionDuration = dur
@@ -215,10 +485,61 @@ func (h *Handlers) showExportOptions() {
 			options.TransitionDuration = 1.0
 		}
 
+		if canvas, err := ParseCanvas(canvasSelect.Selected); err == nil {
+			options.Canvas = canvas
+		}
+		if fps, err := strconv.ParseFloat(fpsSelect.Selected, 64); err == nil {
+			options.Canvas.FrameRate = fps
+		}
+
+		options.Loudness.Scope, _ = ParseLoudnessScope(loudnessSelect.Selected)
+		for _, field := range []struct {
+			entry *widget.Entry
+			value *float64
+			name  string
+		}{
+			{targetEntry, &options.Loudness.Target, "loudness target"},
+			{truePeakEntry, &options.Loudness.TruePeak, "true peak"},
+			{rangeEntry, &options.Loudness.Range, "loudness range"},
+		} {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field.entry.Text), 64)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("invalid %s %q", field.name, field.entry.Text), h.window)
+				return
+			}
+			*field.value = value
+		}
+		if err := options.Loudness.Validate(); err != nil {
+			dialog.ShowError(err, h.window)
+			return
+		}
+
+		options.Subtitles, _ = ParseSubtitleMode(subtitleSelect.Selected)
+
+		settings.Export = options
+		h.state.SetSettings(settings)
+
 		h.showFileSaveDialog(options)
 	}, h.window)
 }
 
+// previewClips returns the clips the export dialog previews transitions
+// with: the selected clip and the next one, or the first two. Both are nil
+// if there aren't two loaded video clips to use.
+func (h *Handlers) previewClips() (*Video, *Video) {
+	videos := h.state.GetVideos()
+	if len(videos) < 2 {
+		return nil, nil
+	}
+
+	i := min(max(h.state.GetSelected(), 0), len(videos)-2)
+	a, b := videos[i], videos[i+1]
+	if a.Status != VideoReady || b.Status != VideoReady || a.IsTitle() || b.IsTitle() {
+		return nil, nil
+	}
+	return a, b
+}
+
 func (h *Handlers) showFileSaveDialog(options ExportOptions) {
 	fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
 		if err != nil {
@@ -239,21 +560,53 @@ func (h *Handlers) showFileSaveDialog(options ExportOptions) {
 		videos := h.state.GetVideos()
 		progress := make(chan ExportProgress)
 
-		progressDialog := dialog.NewCustom("Exporting", "Cancel", widget.NewLabel("Preparing..."), h.window)
+		statusLabel := widget.NewLabel("Preparing...")
+		progressBar := widget.NewProgressBar()
+		progressBar.Max = 100
+		timeLabel := widget.NewLabel("")
+		detailLabel := widget.NewLabel("")
+		detailLabel.Wrapping = fyne.TextWrapWord
+		detailLabel.Hide()
+		content := container.NewVBox(statusLabel, progressBar, timeLabel, detailLabel)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		progressDialog := dialog.NewCustom("Exporting", "Cancel", content, h.window)
+		progressDialog.SetOnClosed(cancel)
 		progressDialog.Show()
 
-		go ExportVideos(videos, outputPath, options, progress)
+		go ExportVideos(ctx, videos, outputPath, options, progress)
 
 		go func() {
 			for p := range progress {
-				if p.Error != nil {
-					progressDialog.Hide()
-					dialog.ShowError(p.Error, h.window)
-					return
-				}
-				if p.Done {
-					progressDialog.Hide()
-					dialog.ShowInformation("Export Complete", "Video exported successfully to:\n"+outputPath, h.window)
+				fyne.Do(func() {
+					statusLabel.SetText(p.Status)
+					if p.Detail != "" {
+						detailLabel.SetText(p.Detail)
+						detailLabel.Show()
+					}
+					progressBar.SetValue(p.Percent)
+					if p.Elapsed > 0 {
+						timeText := "Elapsed " + FormatTimecode(p.Elapsed)
+						if p.ETA > 0 {
+							timeText += " | Remaining " + FormatTimecode(p.ETA)
+						}
+						timeLabel.SetText(timeText)
+					}
+
+					switch {
+					case p.Cancelled:
+						dialog.ShowInformation("Export Cancelled", "The export was cancelled and the partial file was removed.", h.window)
+					case p.Error != nil:
+						progressDialog.Hide()
+						dialog.ShowError(p.Error, h.window)
+					case p.Done:
+						progressDialog.Hide()
+						dialog.ShowInformation("Export Complete", "Video exported successfully to:\n"+outputPath, h.window)
+					}
+				})
+
+				if p.Cancelled || p.Error != nil || p.Done {
 					return
 				}
 			}
@@ -282,11 +635,13 @@ func (h *Handlers) OnSave() {
 		outputPath := writer.URI().Path()
 		writer.Close()
 
-		videos := h.state.GetVideos()
-		if err := SaveProject(videos, outputPath); err != nil {
+		project := h.state.Project()
+		if err := SaveProject(project, outputPath); err != nil {
 			dialog.ShowError(err, h.window)
 			return
 		}
+		h.state.SetSettings(project.Settings())
+		h.updateTitle()
 
 		dialog.ShowInformation("Save Project", "Project saved successfully.", h.window)
 	}, h.window)
@@ -308,27 +663,124 @@ func (h *Handlers) OnLoad() {
 		path := reader.URI().Path()
 		reader.Close()
 
-		videoPaths, err := LoadProject(path)
+		project, err := LoadProject(path)
+		if err != nil {
+			dialog.ShowError(err, h.window)
+			return
+		}
+		h.openProject(project)
+	}, h.window)
+
+	fd.SetFilter(&jsonFilter{})
+	fd.Show()
+}
+
+// openProject replaces the clips and settings with those of project and
+// loads its clips in the background.
+func (h *Handlers) openProject(project *Project) {
+	videos := make([]*Video, len(project.Clips))
+	for i, clip := range project.Clips {
+		var err error
+		if videos[i], err = clip.NewVideo(); err != nil {
+			log.Printf("Ignoring trim points for %s: %v", clip.Path, err)
+		}
+	}
+
+	h.OnCancelImport()
+	h.state.Clear()
+	h.loadPlaceholders(videos, func(results []ImportResult) {
+		var messages []string
+		if summary := ImportSummary(results); summary != "" {
+			messages = append(messages, "Project loaded with errors.\n"+summary)
+		}
+
+		var changed []string
+		for _, video := range videos {
+			if video.SourceChanged {
+				changed = append(changed, video.Name)
+			}
+		}
+		if len(changed) > 0 {
+			messages = append(messages, "These files changed since the project was saved. Check their trim points:\n"+strings.Join(changed, "\n"))
+		}
+
+		if len(messages) > 0 {
+			dialog.ShowInformation("Load Project", strings.Join(messages, "\n\n"), h.window)
+		}
+	})
+	h.state.SetSelection(project.Selected, project.Selection)
+	h.state.SetSettings(project.Settings())
+	h.state.ResetHistory()
+	h.updateTitle()
+
+	if offline := countOffline(videos); offline > 0 {
+		message := fmt.Sprintf("%d clips could not be found and are offline.\nSearch a folder for them now?", offline)
+		dialog.ShowConfirm("Missing Media", message, func(ok bool) {
+			if ok {
+				h.OnRelink()
+			}
+		}, h.window)
+	}
+}
+
+// OnRelink searches a folder for the files of all offline clips and
+// points the clips at the files it finds.
+func (h *Handlers) OnRelink() {
+	if countOffline(h.state.GetVideos()) == 0 {
+		dialog.ShowInformation("Relink Media", "No clips are offline.", h.window)
+		return
+	}
+
+	fd := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
 		if err != nil {
 			dialog.ShowError(err, h.window)
 			return
 		}
+		if uri == nil {
+			return
+		}
 
-		h.state.Clear()
+		videos := h.state.GetVideos()
+		relinks, err := FindRelinks(videos, uri.Path())
+		if err != nil {
+			dialog.ShowError(err, h.window)
+			return
+		}
 
-		for _, videoPath := range videoPaths {
-			if err := h.state.AddVideo(videoPath); err != nil {
-				log.Printf("Failed to load video %s: %v", videoPath, err)
+		var notFound []string
+		for _, video := range videos {
+			if _, ok := relinks[video]; video.Status == VideoOffline && !ok {
+				notFound = append(notFound, video.Name)
 			}
 		}
 
-		dialog.ShowInformation("Load Project", "Project loaded successfully.", h.window)
+		message := fmt.Sprintf("Relinked %d of %d offline clips.", len(relinks), len(relinks)+len(notFound))
+		if len(notFound) > 0 {
+			message += "\nNot found:\n" + strings.Join(notFound, "\n")
+		}
+		dialog.ShowInformation("Relink Media", message, h.window)
+
+		ctx := h.importContext()
+		h.state.Relink(ctx, relinks, h.importConcurrency(), h.importDone(ctx, func(results []ImportResult) {
+			if summary := ImportSummary(results); summary != "" {
+				dialog.ShowInformation("Relink Media", summary, h.window)
+			}
+		}))
 	}, h.window)
 
-	fd.SetFilter(&jsonFilter{})
 	fd.Show()
 }
 
+func countOffline(videos []*Video) int {
+	n := 0
+	for _, video := range videos {
+		if video.Status == VideoOffline {
+			n++
+		}
+	}
+	return n
+}
+
 type videoFilter struct{}
 
 func (f *videoFilter) Matches(uri fyne.URI) bool {
@@ -0,0 +1,179 @@
+package app
+
+import "time"
+
+// maxHistory is the number of steps kept on the undo stack.
+const maxHistory = 200
+
+// command is a reversible change to the State. apply and revert are called
+// with the state lock held.
+type command interface {
+	apply(s *State)
+	revert(s *State)
+}
+
+// listCommand replaces the clip list and selection. It covers adding,
+// removing, reordering and clearing clips.
+type listCommand struct {
+	before, after       []*Video
+	selBefore, selAfter selectionState
+}
+
+func (c *listCommand) apply(s *State) {
+	s.videos = append([]*Video(nil), c.after...)
+	s.restoreSelection(c.selAfter)
+}
+
+func (c *listCommand) revert(s *State) {
+	s.videos = append([]*Video(nil), c.before...)
+	s.restoreSelection(c.selBefore)
+}
+
+// trimCommand changes the trim points of a single clip.
+type trimCommand struct {
+	video         *Video
+	oldIn, oldOut time.Duration
+	newIn, newOut time.Duration
+}
+
+func (c *trimCommand) apply(s *State) {
+	c.video.InPoint, c.video.OutPoint = c.newIn, c.newOut
+}
+
+func (c *trimCommand) revert(s *State) {
+	c.video.InPoint, c.video.OutPoint = c.oldIn, c.oldOut
+}
+
+// transitionCommand changes the transition after a single clip.
+type transitionCommand struct {
+	video    *Video
+	old, new *Transition
+}
+
+func (c *transitionCommand) apply(s *State) {
+	c.video.TransitionOut = c.new
+}
+
+func (c *transitionCommand) revert(s *State) {
+	c.video.TransitionOut = c.old
+}
+
+// chapterCommand changes the chapter title of a single clip.
+type chapterCommand struct {
+	video    *Video
+	old, new string
+}
+
+func (c *chapterCommand) apply(s *State) {
+	c.video.ChapterTitle = c.new
+}
+
+func (c *chapterCommand) revert(s *State) {
+	c.video.ChapterTitle = c.old
+}
+
+// titleCommand changes the text and look of a title card.
+type titleCommand struct {
+	video    *Video
+	old, new TitleCard
+}
+
+func (c *titleCommand) apply(s *State) {
+	c.video.setTitle(c.new)
+	go s.renderTitleThumbnail(c.video)
+}
+
+func (c *titleCommand) revert(s *State) {
+	c.video.setTitle(c.old)
+	go s.renderTitleThumbnail(c.video)
+}
+
+// overlaysCommand changes the text overlays of a single clip.
+type overlaysCommand struct {
+	video    *Video
+	old, new []TextOverlay
+}
+
+func (c *overlaysCommand) apply(s *State) {
+	c.video.Overlays = c.new
+}
+
+func (c *overlaysCommand) revert(s *State) {
+	c.video.Overlays = c.old
+}
+
+// execute applies cmd and records it for undo. The caller holds the lock.
+func (s *State) execute(cmd command) {
+	cmd.apply(s)
+
+	s.undoStack = append(s.undoStack, cmd)
+	if len(s.undoStack) > maxHistory {
+		s.undoStack = s.undoStack[len(s.undoStack)-maxHistory:]
+	}
+	s.redoStack = nil
+}
+
+// changeList records a replacement of the clip list and the selection that
+// goes with it. The caller holds the lock.
+func (s *State) changeList(videos []*Video, sel selectionState) {
+	s.execute(&listCommand{
+		before:    append([]*Video(nil), s.videos...),
+		after:     videos,
+		selBefore: s.currentSelection(),
+		selAfter:  sel,
+	})
+}
+
+func (s *State) Undo() {
+	s.mu.Lock()
+	if len(s.undoStack) == 0 {
+		s.mu.Unlock()
+		return
+	}
+
+	cmd := s.undoStack[len(s.undoStack)-1]
+	s.undoStack = s.undoStack[:len(s.undoStack)-1]
+	cmd.revert(s)
+	s.redoStack = append(s.redoStack, cmd)
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+func (s *State) Redo() {
+	s.mu.Lock()
+	if len(s.redoStack) == 0 {
+		s.mu.Unlock()
+		return
+	}
+
+	cmd := s.redoStack[len(s.redoStack)-1]
+	s.redoStack = s.redoStack[:len(s.redoStack)-1]
+	cmd.apply(s)
+	s.undoStack = append(s.undoStack, cmd)
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+func (s *State) CanUndo() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.undoStack) > 0
+}
+
+func (s *State) CanRedo() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.redoStack) > 0
+}
+
+// ResetHistory forgets all undo and redo steps, e.g. after loading a project.
+func (s *State) ResetHistory() {
+	s.mu.Lock()
+	s.undoStack = nil
+	s.redoStack = nil
+	s.mu.Unlock()
+}
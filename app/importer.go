@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type ImportResult struct {
+	Path  string
+	Video *Video
+	Err   error
+}
+
+// ImportVideos loads paths with up to concurrency workers, using NewVideo
+// or ProbeVideo as load. Probing is dominated by ffmpeg start-up time, so
+// running several at once speeds up large imports. Results are returned in
+// the order of paths. onResult, if set, is called from the worker as soon
+// as each file has loaded, with its index in paths. Cancelling ctx stops
+// starting new files; those get ctx.Err() as their error.
+func ImportVideos(ctx context.Context, paths []string, concurrency int, load func(path string) (*Video, error), onResult func(index int, result ImportResult)) []ImportResult {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	concurrency = min(concurrency, len(paths))
+
+	results := make([]ImportResult, len(paths))
+	for i, path := range paths {
+		results[i].Path = path
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				video, err := load(paths[i])
+				results[i].Video = video
+				results[i].Err = err
+
+				if onResult != nil {
+					onResult(i, results[i])
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range paths {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(paths); j++ {
+				results[j].Err = ctx.Err()
+			}
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// ImportSummary describes the files that failed to load, or returns "" if
+// all of them loaded.
+func ImportSummary(results []ImportResult) string {
+	var failures []string
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", result.Path, result.Err))
+		}
+	}
+	if len(failures) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d files could not be loaded:\n%s", len(failures), len(results), strings.Join(failures, "\n"))
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"image"
+	"log"
+	"reflect"
+	"slices"
+)
+
+// AddPlaceholders appends videos created with NewPlaceholder as a single
+// undo step, then loads their metadata and thumbnails in the background
+// with up to concurrency workers. Each row is refreshed on its own as its
+// data arrives. onDone, if set, is called with the results of all videos
+// once loading finishes or ctx is cancelled.
+func (s *State) AddPlaceholders(ctx context.Context, videos []*Video, concurrency int, onDone func(results []ImportResult)) {
+	s.AppendVideos(videos)
+	s.loadInBackground(ctx, videos, concurrency, onDone)
+}
+
+// loadInBackground probes the placeholders among videos, which are already
+// in the list. See AddPlaceholders.
+func (s *State) loadInBackground(ctx context.Context, videos []*Video, concurrency int, onDone func(results []ImportResult)) {
+	var pending []*Video
+	var paths []string
+	for _, video := range videos {
+		if video.Status == VideoProbing {
+			pending = append(pending, video)
+			paths = append(paths, video.Path)
+		}
+	}
+
+	s.mu.Lock()
+	s.importTotal += len(pending)
+	s.mu.Unlock()
+
+	for _, video := range videos {
+		if video.IsTitle() && video.Thumbnail == nil {
+			go s.renderTitleThumbnail(video)
+		}
+	}
+
+	go func() {
+		ImportVideos(ctx, paths, concurrency, ProbeVideo, func(i int, result ImportResult) {
+			s.finishProbe(pending[i], result.Video, result.Err)
+			if result.Err != nil || result.Video.Status == VideoFailed {
+				return
+			}
+
+			video := pending[i]
+			if thumb, err := LoadThumbnail(video.Path, video.Size, video.ModTime); err == nil {
+				s.setThumbnail(video, thumb)
+			}
+		})
+
+		// Videos that were never started because the import was cancelled
+		for _, video := range pending {
+			s.finishProbe(video, nil, ctx.Err())
+		}
+
+		if onDone != nil {
+			results := make([]ImportResult, len(videos))
+			for i, video := range videos {
+				results[i] = ImportResult{Path: video.Path, Video: video, Err: video.LoadErr}
+			}
+			onDone(results)
+		}
+	}()
+}
+
+// finishProbe copies the probed metadata into a placeholder, or marks it
+// as failed. Videos that are no longer loading are left alone.
+func (s *State) finishProbe(video, probed *Video, err error) {
+	s.mu.Lock()
+	if video.Status != VideoProbing {
+		s.mu.Unlock()
+		return
+	}
+
+	switch {
+	case err != nil:
+		video.Status = VideoFailed
+		video.LoadErr = err
+	default:
+		video.Size = probed.Size
+		video.ModTime = probed.ModTime
+		video.Hash = probed.Hash
+		video.Duration = probed.Duration
+		video.Width = probed.Width
+		video.Height = probed.Height
+		video.HasAudio = probed.HasAudio
+		video.Media = probed.Media
+		video.Status = probed.Status
+		video.LoadErr = probed.LoadErr
+		video.checkSource()
+	}
+
+	s.importDone++
+	if s.importDone >= s.importTotal {
+		s.importDone, s.importTotal = 0, 0
+	}
+	index := slices.Index(s.videos, video)
+	s.mu.Unlock()
+
+	s.notifyVideoChange(index)
+}
+
+func (s *State) setThumbnail(video *Video, thumb image.Image) {
+	s.mu.Lock()
+	video.Thumbnail = thumb
+	index := slices.Index(s.videos, video)
+	s.mu.Unlock()
+
+	s.notifyVideoChange(index)
+}
+
+// renderTitleThumbnail renders the list thumbnail of a title card.
+func (s *State) renderTitleThumbnail(video *Video) {
+	s.mu.RLock()
+	card := *video.Title
+	s.mu.RUnlock()
+
+	thumb, err := RenderTitleThumbnail(card)
+	if err != nil {
+		log.Printf("Failed to render title card thumbnail: %v", err)
+		return
+	}
+
+	// Skip the result if the card was edited in the meantime
+	s.mu.RLock()
+	current := video.Title != nil && reflect.DeepEqual(*video.Title, card)
+	s.mu.RUnlock()
+	if current {
+		s.setThumbnail(video, thumb)
+	}
+}
+
+// ImportProgress returns how many videos of the current background import
+// have finished loading. Both are zero when nothing is loading.
+func (s *State) ImportProgress() (done, total int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.importDone, s.importTotal
+}
+
+// SetOnVideoChange sets a callback for changes to a single video's data
+// that don't affect the rest of the list, such as a finished probe.
+func (s *State) SetOnVideoChange(fn func(index int)) {
+	s.onVideoChange = fn
+}
+
+func (s *State) notifyVideoChange(index int) {
+	if s.onVideoChange != nil {
+		s.onVideoChange(index)
+		return
+	}
+	s.notifyChange()
+}
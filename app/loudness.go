@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoudnessScope is what loudness normalization is applied to.
+type LoudnessScope string
+
+const (
+	LoudnessOff     LoudnessScope = ""
+	LoudnessProgram LoudnessScope = "program" // the whole export, music included
+	LoudnessClip    LoudnessScope = "clip"    // each clip on its own, before the music
+)
+
+// LoudnessScopes lists the scopes in the order they are offered.
+var LoudnessScopes = []LoudnessScope{LoudnessOff, LoudnessProgram, LoudnessClip}
+
+func (s LoudnessScope) String() string {
+	switch s {
+	case LoudnessProgram:
+		return "Whole program"
+	case LoudnessClip:
+		return "Each clip"
+	}
+	return "Off"
+}
+
+// ParseLoudnessScope accepts a scope by its id ("off", "program", "clip")
+// or its display name.
+func ParseLoudnessScope(name string) (LoudnessScope, error) {
+	name = strings.TrimSpace(name)
+	for _, s := range LoudnessScopes {
+		if strings.EqualFold(name, string(s)) || strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return LoudnessOff, fmt.Errorf("unknown loudness scope %q (want off, program or clip)", name)
+}
+
+// Loudness configures EBU R128 loudness normalization. Audio is measured
+// in a first pass and then adjusted with a constant gain by ffmpeg's
+// loudnorm filter, which only falls back to dynamic compression when the
+// target can't be met without exceeding the true peak or loudness range.
+type Loudness struct {
+	Scope    LoudnessScope `json:"scope"`
+	Target   float64       `json:"target"`   // integrated loudness in LUFS
+	TruePeak float64       `json:"truePeak"` // maximum true peak in dBTP
+	Range    float64       `json:"range"`    // loudness range in LU
+}
+
+// DefaultLoudness returns the EBU R128 broadcast targets, with
+// normalization turned off.
+func DefaultLoudness() Loudness {
+	return Loudness{Target: -23, TruePeak: -1, Range: 7}
+}
+
+// Enabled reports whether audio is normalized.
+func (l Loudness) Enabled() bool {
+	return l.Scope != LoudnessOff
+}
+
+// Validate checks the targets against the ranges loudnorm accepts.
+func (l Loudness) Validate() error {
+	if !l.Enabled() {
+		return nil
+	}
+	switch {
+	case l.Target < -70 || l.Target > -5:
+		return fmt.Errorf("loudness target %.1f LUFS is out of range (-70 to -5)", l.Target)
+	case l.TruePeak < -9 || l.TruePeak > 0:
+		return fmt.Errorf("true peak %.1f dBTP is out of range (-9 to 0)", l.TruePeak)
+	case l.Range < 1 || l.Range > 50:
+		return fmt.Errorf("loudness range %.1f LU is out of range (1 to 50)", l.Range)
+	}
+	return nil
+}
+
+func (l Loudness) targets() string {
+	return fmt.Sprintf("I=%.1f:TP=%.1f:LRA=%.1f", l.Target, l.TruePeak, l.Range)
+}
+
+// measureFilter prints the measured loudness as JSON at the end of the
+// pass.
+func (l Loudness) measureFilter() string {
+	return "loudnorm=" + l.targets() + ":print_format=json"
+}
+
+// applyFilter normalizes audio with the values of a measurement pass.
+// loudnorm works at 192 kHz, so the result is resampled to sampleRate.
+func (l Loudness) applyFilter(m loudnessMeasurement, sampleRate int) string {
+	return fmt.Sprintf("loudnorm=%s:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true,aresample=%d",
+		l.targets(), m.InputI, m.InputTP, m.InputLRA, m.InputThresh, m.TargetOffset, sampleRate)
+}
+
+// loudnessMeasurement holds the values loudnorm prints after a
+// measurement pass, kept as the strings it prints so they can be passed
+// back unchanged.
+type loudnessMeasurement struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+func (m loudnessMeasurement) String() string {
+	return fmt.Sprintf("%s LUFS, true peak %s dBTP, range %s LU", m.InputI, m.InputTP, m.InputLRA)
+}
+
+// silent reports whether the audio had nothing to measure, in which case
+// loudnorm prints -inf and there is no gain to apply.
+func (m loudnessMeasurement) silent() bool {
+	i, err := strconv.ParseFloat(m.InputI, 64)
+	return err != nil || math.IsInf(i, 0)
+}
+
+// parseLoudnessMeasurement finds the JSON block loudnorm prints at the end
+// of ffmpeg's log.
+func parseLoudnessMeasurement(output string) (loudnessMeasurement, error) {
+	var m loudnessMeasurement
+
+	start := strings.LastIndex(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return m, fmt.Errorf("no loudness measurement in ffmpeg output")
+	}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &m); err != nil {
+		return m, fmt.Errorf("failed to parse loudness measurement: %w", err)
+	}
+	return m, nil
+}
+
+// measureLoudness runs a measurement pass over the audio of the given
+// inputs.
+func measureLoudness(ctx context.Context, inputs []string, l Loudness, expected time.Duration, status string, progress chan<- ExportProgress) (loudnessMeasurement, error) {
+	args := append(inputs, "-vn", "-af", l.measureFilter(), "-f", "null", "-")
+	output, err := runFFmpegLog(ctx, args, expected, status, progress)
+	if err != nil {
+		return loudnessMeasurement{}, err
+	}
+	return parseLoudnessMeasurement(output)
+}
+
+// measureClips measures every clip with audio and returns the filter that
+// normalizes each one, "" for clips that are left as they are.
+func measureClips(ctx context.Context, videos []*Video, l Loudness, sampleRate int, progress chan<- ExportProgress) ([]string, error) {
+	filters := make([]string, len(videos))
+	var measured []string
+
+	for i, video := range videos {
+		if video.IsTitle() || !video.HasAudio {
+			continue
+		}
+
+		status := fmt.Sprintf("Measuring loudness of clip %d of %d...", i+1, len(videos))
+		m, err := measureLoudness(ctx, inputArgs(video, nil), l, video.TrimmedDuration(), status, progress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure the loudness of %s: %w", video.Name, err)
+		}
+
+		log.Printf("Loudness of %s: %s", video.Name, m)
+		measured = append(measured, fmt.Sprintf("%s: %s", video.Name, m))
+		if !m.silent() {
+			filters[i] = l.applyFilter(m, sampleRate)
+		}
+	}
+
+	progress <- ExportProgress{
+		Status: fmt.Sprintf("Normalizing clips to %.1f LUFS", l.Target),
+		Detail: "Measured loudness:\n" + strings.Join(measured, "\n"),
+	}
+	return filters, nil
+}
+
+// normalizeProgram measures the loudness of a rendered export and writes
+// it to outputPath with the audio normalized and the video copied.
+func normalizeProgram(ctx context.Context, input, outputPath string, l Loudness, sampleRate int, expected time.Duration, progress chan<- ExportProgress) error {
+	m, err := measureLoudness(ctx, []string{"-i", input}, l, expected, "Measuring loudness...", progress)
+	if err != nil {
+		return fmt.Errorf("failed to measure loudness: %w", err)
+	}
+
+	log.Printf("Program loudness: %s", m)
+	progress <- ExportProgress{
+		Status: fmt.Sprintf("Normalizing to %.1f LUFS", l.Target),
+		Detail: "Measured loudness: " + m.String(),
+	}
+
+	// Chapters are carried over from the intermediate file, and so is the
+	// subtitle track if there is one
+	args := []string{"-i", input, "-map", "0:v", "-map", "0:a", "-c:v", "copy"}
+	if codec := subtitleCodec(outputPath); codec != "" {
+		args = append(args, "-map", "0:s?", "-c:s", codec)
+	}
+	if !m.silent() {
+		args = append(args, "-af", l.applyFilter(m, sampleRate))
+	}
+	args = append(args, outputArgs(outputPath)...)
+
+	return runFFmpeg(ctx, args, expected, "Normalizing loudness...", progress)
+}
+
+// intermediateFile returns a temporary file next to outputPath for the
+// export before its loudness is normalized. Matroska holds any video codec
+// along with uncompressed audio, so the audio is only encoded once.
+func intermediateFile(outputPath string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(outputPath), ".video-arranger-*.mkv")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	f.Close()
+	return f.Name(), nil
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MusicBed is background music laid under the whole program. The tracks
+// play one after another, repeating if Loop is set, and are cut off at the
+// end of the program.
+type MusicBed struct {
+	Tracks  []MusicTrack `json:"tracks"`
+	Volume  float64      `json:"volume"`  // linear gain, 1 keeps the original level
+	FadeIn  float64      `json:"fadeIn"`  // in seconds
+	FadeOut float64      `json:"fadeOut"` // in seconds
+	Loop    bool         `json:"loop"`
+	// Duck lowers the music while the clips have speech
+	Duck bool `json:"duck"`
+}
+
+// MusicTrack is an audio file of the music bed. Like clip paths, Path is
+// relative to the project file when saved, with the absolute path kept in
+// AbsPath as a fallback.
+type MusicTrack struct {
+	Path    string `json:"path"`
+	AbsPath string `json:"absPath,omitempty"`
+}
+
+// musicExtensions are the audio files offered when adding music.
+var musicExtensions = []string{".mp3", ".m4a", ".aac", ".wav", ".flac", ".ogg", ".opus", ".aiff"}
+
+// DefaultMusicBed returns the settings music starts with when the first
+// track is added.
+func DefaultMusicBed() MusicBed {
+	return MusicBed{Volume: 0.3, FadeIn: 2, FadeOut: 3, Loop: true, Duck: true}
+}
+
+// Enabled reports whether there is any music to mix.
+func (m MusicBed) Enabled() bool {
+	return len(m.Tracks) > 0
+}
+
+// check returns an error if a track is missing, so the export fails before
+// ffmpeg starts.
+func (m MusicBed) check() error {
+	for _, track := range m.Tracks {
+		if _, err := os.Stat(track.Path); err != nil {
+			return fmt.Errorf("music track %s not found", filepath.Base(track.Path))
+		}
+	}
+	return nil
+}
+
+// musicMix is a music bed prepared for one export.
+type musicMix struct {
+	bed        MusicBed
+	sampleRate int
+	length     float64 // of the program, in seconds
+}
+
+// newMusicMix returns the mix for an export of the given length, or nil if
+// the options have no music.
+func newMusicMix(options ExportOptions, sampleRate int, length float64) *musicMix {
+	if !options.Music.Enabled() {
+		return nil
+	}
+	return &musicMix{bed: options.Music, sampleRate: sampleRate, length: length}
+}
+
+// inputArgs returns the ffmpeg inputs for the tracks, added after the
+// clips.
+func (m *musicMix) inputArgs() []string {
+	var args []string
+	for _, track := range m.bed.Tracks {
+		args = append(args, "-i", track.Path)
+	}
+	return args
+}
+
+// filters mixes the music under the program audio label, with the tracks
+// read from inputs starting at firstInput. The result is [aout].
+//
+// Ducking runs the music through a compressor keyed by the program audio,
+// band-limited to the speech range so that music in the clips themselves
+// doesn't trigger it as easily.
+func (m *musicMix) filters(program string, firstInput int) []string {
+	var chains []string
+
+	var tracks string
+	for i := range m.bed.Tracks {
+		chains = append(chains, fmt.Sprintf("[%d:a]aresample=%d,aformat=sample_fmts=fltp:channel_layouts=stereo[mt%d]",
+			firstInput+i, m.sampleRate, i))
+		tracks += fmt.Sprintf("[mt%d]", i)
+	}
+
+	bed := tracks
+	if len(m.bed.Tracks) > 1 {
+		bed += fmt.Sprintf("concat=n=%d:v=0:a=1,", len(m.bed.Tracks))
+	}
+	if m.bed.Loop {
+		bed += "aloop=loop=-1:size=2147483647,"
+	}
+
+	// Trim or pad to the program, then set the level and fade the ends
+	bed += fmt.Sprintf("atrim=duration=%.3f,apad=whole_dur=%.3f,volume=%.3f", m.length, m.length, m.bed.Volume)
+	if fadeIn := min(m.bed.FadeIn, m.length/2); fadeIn > 0 {
+		bed += fmt.Sprintf(",afade=t=in:st=0:d=%.3f", fadeIn)
+	}
+	if fadeOut := min(m.bed.FadeOut, m.length/2); fadeOut > 0 {
+		bed += fmt.Sprintf(",afade=t=out:st=%.3f:d=%.3f", m.length-fadeOut, fadeOut)
+	}
+	chains = append(chains, bed+"[music]")
+
+	music := "[music]"
+	if m.bed.Duck {
+		chains = append(chains,
+			program+"asplit=2[aprog][akey]",
+			"[akey]highpass=f=300,lowpass=f=3400[avoice]",
+			"[music][avoice]sidechaincompress=threshold=0.02:ratio=8:attack=20:release=600[mducked]")
+		program, music = "[aprog]", "[mducked]"
+	}
+
+	return append(chains, program+music+"amix=inputs=2:duration=first:dropout_transition=0:normalize=0[aout]")
+}
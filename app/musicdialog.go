@@ -0,0 +1,133 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// OnMusic edits the background music laid under the whole project.
+func (h *Handlers) OnMusic() {
+	settings := h.state.Settings()
+	bed := settings.Export.Music
+	if !bed.Enabled() {
+		bed = DefaultMusicBed()
+	}
+	tracks := slices.Clone(bed.Tracks)
+
+	trackList := container.NewVBox()
+	var refreshTracks func()
+	refreshTracks = func() {
+		trackList.RemoveAll()
+		if len(tracks) == 0 {
+			trackList.Add(widget.NewLabel("No music"))
+		}
+		for i, track := range tracks {
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				tracks = slices.Delete(tracks, i, i+1)
+				refreshTracks()
+			})
+			remove.Importance = widget.LowImportance
+			label := widget.NewLabel(fmt.Sprintf("%d. %s", i+1, filepath.Base(track.Path)))
+			label.Truncation = fyne.TextTruncateEllipsis
+			trackList.Add(container.NewBorder(nil, nil, nil, remove, label))
+		}
+		trackList.Refresh()
+	}
+	refreshTracks()
+
+	addButton := widget.NewButtonWithIcon("Add Music...", theme.ContentAddIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, h.window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+
+			tracks = append(tracks, MusicTrack{Path: path})
+			refreshTracks()
+		}, h.window)
+		fd.SetFilter(&audioFilter{})
+		fd.Show()
+	})
+
+	volumeLabel := widget.NewLabel("")
+	volumeSlider := widget.NewSlider(0, 100)
+	volumeSlider.Step = 5
+	volumeSlider.OnChanged = func(value float64) {
+		volumeLabel.SetText(fmt.Sprintf("%.0f%%", value))
+	}
+	volumeSlider.SetValue(bed.Volume * 100)
+
+	fadeInEntry := widget.NewEntry()
+	fadeInEntry.SetText(strconv.FormatFloat(bed.FadeIn, 'f', -1, 64))
+	fadeOutEntry := widget.NewEntry()
+	fadeOutEntry.SetText(strconv.FormatFloat(bed.FadeOut, 'f', -1, 64))
+
+	loopCheck := widget.NewCheck("Loop to fill the whole project", nil)
+	loopCheck.SetChecked(bed.Loop)
+	duckCheck := widget.NewCheck("Lower the music while clips have speech", nil)
+	duckCheck.SetChecked(bed.Duck)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Tracks", container.NewBorder(nil, addButton, nil, nil, trackList)),
+		widget.NewFormItem("Volume", container.NewBorder(nil, nil, nil, volumeLabel, volumeSlider)),
+		widget.NewFormItem("Fade in (sec)", fadeInEntry),
+		widget.NewFormItem("Fade out (sec)", fadeOutEntry),
+		widget.NewFormItem("", loopCheck),
+		widget.NewFormItem("", duckCheck),
+	)
+
+	d := dialog.NewCustomConfirm("Background Music", "OK", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		edited := MusicBed{
+			Tracks: tracks,
+			Volume: volumeSlider.Value / 100,
+			Loop:   loopCheck.Checked,
+			Duck:   duckCheck.Checked,
+		}
+		var err error
+		if edited.FadeIn, err = parseSecondsEntry(fadeInEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("invalid fade in %q", strings.TrimSpace(fadeInEntry.Text)), h.window)
+			return
+		}
+		if edited.FadeOut, err = parseSecondsEntry(fadeOutEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("invalid fade out %q", strings.TrimSpace(fadeOutEntry.Text)), h.window)
+			return
+		}
+		if !edited.Enabled() {
+			edited = MusicBed{}
+		}
+
+		settings := h.state.Settings()
+		settings.Export.Music = edited
+		h.state.SetSettings(settings)
+	}, h.window)
+	d.Resize(fyne.NewSize(450, 0))
+	d.Show()
+}
+
+type audioFilter struct{}
+
+func (f *audioFilter) Matches(uri fyne.URI) bool {
+	return slices.Contains(musicExtensions, strings.ToLower(filepath.Ext(uri.Path())))
+}
+
+func (f *audioFilter) Extensions() []string {
+	return musicExtensions
+}
@@ -0,0 +1,87 @@
+package app
+
+import "fmt"
+
+// normalization is the common format that mismatched clips are converted
+// to before they are joined.
+type normalization struct {
+	canvas     Canvas
+	sampleRate int
+}
+
+func newNormalization(report CompatibilityReport, canvas Canvas) *normalization {
+	return &normalization{
+		canvas:     report.ResolveCanvas(canvas),
+		sampleRate: report.SampleRate(),
+	}
+}
+
+// streamInputs holds the filter graph labels for each clip's video and
+// audio, plus any filters needed to produce them.
+type streamInputs struct {
+	filters []string
+	video   []string
+	audio   []string
+}
+
+// buildStreamInputs returns the labels the filter builders read clip i
+// from. Without normalization these are the raw input streams; otherwise
+// every clip is scaled and padded to the canvas, converted to a common
+// frame rate and pixel format, its text overlays drawn and its audio
+// resampled. Clips without an audio stream get a generated silent track of
+// the clip's length.
+func buildStreamInputs(videos []*Video, norm *normalization) streamInputs {
+	var inputs streamInputs
+
+	sampleRate := defaultSampleRate
+	if norm != nil {
+		sampleRate = norm.sampleRate
+	}
+
+	for i, video := range videos {
+		if !video.HasAudio {
+			inputs.filters = append(inputs.filters,
+				fmt.Sprintf("anullsrc=channel_layout=stereo:sample_rate=%d,atrim=duration=%.3f,aformat=sample_fmts=fltp[sa%d]",
+					sampleRate, video.TrimmedDuration().Seconds(), i))
+			inputs.audio = append(inputs.audio, fmt.Sprintf("[sa%d]", i))
+		}
+
+		if norm == nil {
+			inputs.video = append(inputs.video, fmt.Sprintf("[%d:v]", i))
+			if video.HasAudio {
+				inputs.audio = append(inputs.audio, fmt.Sprintf("[%d:a]", i))
+			}
+			continue
+		}
+
+		w, h := norm.canvas.Width, norm.canvas.Height
+		overlays := overlayFilters(video, h)
+		if overlays != "" {
+			overlays = "," + overlays
+		}
+		inputs.filters = append(inputs.filters,
+			fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%.3f,format=yuv420p%s[nv%d]",
+				i, w, h, w, h, norm.canvas.FrameRate, overlays, i))
+		inputs.video = append(inputs.video, fmt.Sprintf("[nv%d]", i))
+		if video.HasAudio {
+			inputs.filters = append(inputs.filters,
+				fmt.Sprintf("[%d:a]aresample=%d,aformat=sample_fmts=fltp:channel_layouts=stereo[na%d]",
+					i, norm.sampleRate, i))
+			inputs.audio = append(inputs.audio, fmt.Sprintf("[na%d]", i))
+		}
+	}
+
+	return inputs
+}
+
+// buildConcatFilter joins the (normalized) clips back to back. The graph
+// ends in [vout] and [aclips].
+func buildConcatFilter(videos []*Video, inputs streamInputs) []string {
+	var concatInputs string
+	for i := range videos {
+		concatInputs += inputs.video[i] + inputs.audio[i]
+	}
+
+	concatFilter := fmt.Sprintf("%sconcat=n=%d:v=1:a=1[vout][aclips]", concatInputs, len(videos))
+	return append(inputs.filters, concatFilter)
+}
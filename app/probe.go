@@ -0,0 +1,303 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaInfo is the metadata of a media file as reported by ffprobe.
+type MediaInfo struct {
+	Container   string
+	Duration    time.Duration
+	BitRate     int64 // bits per second, whole file
+	Width       int   // coded size, before rotation
+	Height      int
+	VideoCodec  string
+	Profile     string
+	FrameRate   float64
+	VFR         bool // variable frame rate
+	PixelFormat string
+	ColorSpace  string
+	Rotation    int // degrees, as stored in the file
+	Audio       []AudioStreamInfo
+	Subtitles   []SubtitleStreamInfo
+	Languages   []string // languages of all streams, without duplicates
+}
+
+type AudioStreamInfo struct {
+	Codec      string
+	Channels   int
+	SampleRate int
+	Language   string
+}
+
+// SubtitleStreamInfo describes an embedded subtitle stream. Index counts
+// subtitle streams only, as in ffmpeg's "0:s:N" stream specifiers.
+type SubtitleStreamInfo struct {
+	Index    int
+	Codec    string
+	Language string
+	Title    string
+}
+
+// bitmapSubtitleCodecs are subtitle formats stored as pictures, which
+// can't be converted to text.
+var bitmapSubtitleCodecs = map[string]bool{
+	"hdmv_pgs_subtitle": true, "dvd_subtitle": true, "dvb_subtitle": true, "xsub": true,
+}
+
+// IsText reports whether the stream holds text that can be converted to
+// SubRip.
+func (s SubtitleStreamInfo) IsText() bool {
+	return !bitmapSubtitleCodecs[s.Codec]
+}
+
+func (s SubtitleStreamInfo) String() string {
+	var parts []string
+	if s.Language != "" && s.Language != "und" {
+		parts = append(parts, s.Language)
+	}
+	if s.Title != "" {
+		parts = append(parts, s.Title)
+	}
+	parts = append(parts, s.Codec)
+	return strings.Join(parts, ", ")
+}
+
+// DisplaySize returns the size the video is shown at once its rotation
+// metadata is applied, e.g. 1080x1920 for a portrait phone clip stored as
+// 1920x1080 with a 90 degree rotation.
+func (m MediaInfo) DisplaySize() (int, int) {
+	if m.Rotation == 90 || m.Rotation == 270 {
+		return m.Height, m.Width
+	}
+	return m.Width, m.Height
+}
+
+func (m MediaInfo) HasAudio() bool {
+	return len(m.Audio) > 0
+}
+
+// StreamFormat returns the properties compared when deciding whether clips
+// can be joined without re-encoding.
+func (m MediaInfo) StreamFormat() StreamFormat {
+	width, height := m.DisplaySize()
+	format := StreamFormat{
+		VideoCodec:  m.VideoCodec,
+		Width:       width,
+		Height:      height,
+		Rotation:    m.Rotation,
+		FrameRate:   m.FrameRate,
+		PixelFormat: m.PixelFormat,
+	}
+	if m.HasAudio() {
+		format.AudioCodec = m.Audio[0].Codec
+		format.SampleRate = m.Audio[0].SampleRate
+		format.Channels = m.Audio[0].Channels
+	}
+	return format
+}
+
+func (m MediaInfo) FrameRateString() string {
+	if m.FrameRate == 0 {
+		return ""
+	}
+	s := strconv.FormatFloat(m.FrameRate, 'f', -1, 64)
+	if len(s) > 6 {
+		s = fmt.Sprintf("%.3f", m.FrameRate)
+	}
+	if m.VFR {
+		return s + " fps (variable)"
+	}
+	return s + " fps"
+}
+
+func (m MediaInfo) BitRateString() string {
+	switch {
+	case m.BitRate >= 1000000:
+		return fmt.Sprintf("%.1f Mb/s", float64(m.BitRate)/1000000)
+	case m.BitRate > 0:
+		return fmt.Sprintf("%d kb/s", m.BitRate/1000)
+	default:
+		return ""
+	}
+}
+
+type ffprobeOutput struct {
+	Format  ffprobeFormat   `json:"format"`
+	Streams []ffprobeStream `json:"streams"`
+}
+
+type ffprobeFormat struct {
+	FormatName     string `json:"format_name"`
+	FormatLongName string `json:"format_long_name"`
+	Duration       string `json:"duration"`
+	BitRate        string `json:"bit_rate"`
+}
+
+type ffprobeStream struct {
+	CodecType    string            `json:"codec_type"`
+	CodecName    string            `json:"codec_name"`
+	Profile      string            `json:"profile"`
+	Width        int               `json:"width"`
+	Height       int               `json:"height"`
+	PixFmt       string            `json:"pix_fmt"`
+	ColorSpace   string            `json:"color_space"`
+	RFrameRate   string            `json:"r_frame_rate"`
+	AvgFrameRate string            `json:"avg_frame_rate"`
+	Duration     string            `json:"duration"`
+	SampleRate   string            `json:"sample_rate"`
+	Channels     int               `json:"channels"`
+	Tags         map[string]string `json:"tags"`
+	SideDataList []struct {
+		SideDataType string  `json:"side_data_type"`
+		Rotation     float64 `json:"rotation"`
+	} `json:"side_data_list"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+// ProbeMedia reads all metadata of a file with a single ffprobe call.
+func ProbeMedia(path string) (MediaInfo, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-of", "json",
+		path)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return MediaInfo{}, err
+	}
+
+	var result ffprobeOutput
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return MediaInfo{}, err
+	}
+
+	return parseProbeOutput(result)
+}
+
+func parseProbeOutput(result ffprobeOutput) (MediaInfo, error) {
+	info := MediaInfo{
+		Container: result.Format.FormatLongName,
+		Duration:  parseSeconds(result.Format.Duration),
+	}
+	if info.Container == "" {
+		info.Container = result.Format.FormatName
+	}
+	info.BitRate, _ = strconv.ParseInt(result.Format.BitRate, 10, 64)
+
+	haveVideo := false
+	seenLanguages := make(map[string]bool)
+	for _, stream := range result.Streams {
+		if lang := stream.Tags["language"]; lang != "" && lang != "und" && !seenLanguages[lang] {
+			seenLanguages[lang] = true
+			info.Languages = append(info.Languages, lang)
+		}
+
+		switch stream.CodecType {
+		case "video":
+			// Skip cover art and additional video streams
+			if haveVideo || stream.Disposition.AttachedPic == 1 {
+				continue
+			}
+			haveVideo = true
+
+			info.VideoCodec = stream.CodecName
+			info.Profile = stream.Profile
+			info.Width = stream.Width
+			info.Height = stream.Height
+			info.PixelFormat = stream.PixFmt
+			info.ColorSpace = stream.ColorSpace
+			info.Rotation = streamRotation(stream)
+
+			avg := parseFrameRate(stream.AvgFrameRate)
+			base := parseFrameRate(stream.RFrameRate)
+			info.FrameRate = avg
+			if info.FrameRate == 0 {
+				info.FrameRate = base
+			}
+			info.VFR = avg > 0 && base > 0 && math.Abs(avg-base)/base > 0.01
+
+			if info.Duration == 0 {
+				info.Duration = parseSeconds(stream.Duration)
+			}
+		case "audio":
+			sampleRate, _ := strconv.Atoi(stream.SampleRate)
+			info.Audio = append(info.Audio, AudioStreamInfo{
+				Codec:      stream.CodecName,
+				Channels:   stream.Channels,
+				SampleRate: sampleRate,
+				Language:   stream.Tags["language"],
+			})
+		case "subtitle":
+			info.Subtitles = append(info.Subtitles, SubtitleStreamInfo{
+				Index:    len(info.Subtitles),
+				Codec:    stream.CodecName,
+				Language: stream.Tags["language"],
+				Title:    stream.Tags["title"],
+			})
+		}
+	}
+
+	if !haveVideo {
+		return info, fmt.Errorf("no video stream")
+	}
+	return info, nil
+}
+
+// streamRotation returns the rotation from the legacy rotate tag or the
+// display matrix side data, normalized to 0, 90, 180 or 270.
+func streamRotation(stream ffprobeStream) int {
+	rotation := 0.0
+	if tag, ok := stream.Tags["rotate"]; ok {
+		rotation, _ = strconv.ParseFloat(tag, 64)
+	}
+	for _, sd := range stream.SideDataList {
+		if sd.SideDataType == "Display Matrix" {
+			// The display matrix rotation is counter-clockwise
+			rotation = -sd.Rotation
+		}
+	}
+
+	degrees := int(math.Round(rotation/90)) * 90 % 360
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+func parseSeconds(s string) time.Duration {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// parseFrameRate parses ffprobe's rational frame rates such as "30000/1001".
+func parseFrameRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	if !ok {
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	}
+
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
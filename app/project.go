@@ -2,23 +2,142 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// ProjectVersion is the schema version written by SaveProject. Bump it
+// when a change can't be read by older versions, and migrate the old
+// layout in LoadProject.
+const ProjectVersion = 2
+
+// Project is the saved form of a project.
+//
+// Version 1 files have no version field and list the video paths in
+// Videos, optionally with trim points in Clips. Version 2 keeps everything
+// in Clips and adds the project-wide settings.
 type Project struct {
-	Videos []string `json:"videos"`
+	Version   int           `json:"version"`
+	Name      string        `json:"name,omitempty"`
+	Created   time.Time     `json:"created,omitzero"`
+	Modified  time.Time     `json:"modified,omitzero"`
+	Clips     []ProjectClip `json:"clips"`
+	Selected  int           `json:"selected"` // primary selection, -1 for none
+	Selection []int         `json:"selection,omitempty"`
+	Export    ExportOptions `json:"export"`
+
+	// Videos is only read from version 1 files
+	Videos []string `json:"videos,omitempty"`
 }
 
-func SaveProject(videos []*Video, path string) error {
-	project := Project{
-		Videos: make([]string, len(videos)),
+// ProjectClip stores the per-clip settings of a video in the project file.
+// Trim points are stored in seconds.
+//
+// In the file, Path is relative to the project file where possible so a
+// project and its media can be moved together, with the absolute path
+// kept in AbsPath as a fallback. LoadProject resolves both to an absolute
+// Path. Size, ModTime and Hash fingerprint the file so changes since the
+// save can be detected; Size also identifies it when relinking.
+type ProjectClip struct {
+	Path     string    `json:"path"`
+	AbsPath  string    `json:"absPath,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	ModTime  time.Time `json:"modTime,omitzero"`
+	Hash     string    `json:"hash,omitempty"`
+	InPoint  float64   `json:"in,omitempty"`
+	OutPoint float64   `json:"out,omitempty"`
+
+	// Transition after the clip, if it differs from the export default
+	Transition *Transition `json:"transition,omitempty"`
+	Chapter    string      `json:"chapter,omitempty"` // chapter title, if not the clip name
+
+	// Title is set for title cards, which have no Path
+	Title    *TitleCard    `json:"title,omitempty"`
+	Overlays []TextOverlay `json:"overlays,omitempty"`
+}
+
+// ProjectSettings are the project-wide settings kept alongside the clip
+// list.
+type ProjectSettings struct {
+	Name    string
+	Created time.Time
+	Export  ExportOptions
+}
+
+// NewProject captures videos and their settings for saving. selection
+// holds clip indices; primary is -1 when nothing is selected.
+func NewProject(videos []*Video, primary int, selection []int, settings ProjectSettings) *Project {
+	project := &Project{
+		Version:   ProjectVersion,
+		Name:      settings.Name,
+		Created:   settings.Created,
+		Clips:     make([]ProjectClip, len(videos)),
+		Selected:  primary,
+		Selection: selection,
+		Export:    settings.Export,
 	}
 
 	for i, v := range videos {
-		project.Videos[i] = v.Path
+		source := v.Fingerprint()
+		if v.Status == VideoOffline {
+			// Keep the fingerprint of the missing file for relinking
+			source = v.savedSource
+		}
+
+		project.Clips[i] = ProjectClip{
+			Path:       v.Path,
+			Size:       source.Size,
+			ModTime:    source.ModTime,
+			Hash:       source.Hash,
+			InPoint:    v.InPoint.Seconds(),
+			OutPoint:   v.OutPoint.Seconds(),
+			Transition: v.TransitionOut,
+			Chapter:    v.ChapterTitle,
+			Title:      v.Title,
+			Overlays:   v.Overlays,
+		}
 	}
 
-	data, err := json.MarshalIndent(project, "", "  ")
+	return project
+}
+
+// Settings returns the project-wide settings of a loaded project.
+func (p *Project) Settings() ProjectSettings {
+	return ProjectSettings{Name: p.Name, Created: p.Created, Export: p.Export}
+}
+
+// SaveProject writes project to path in the current schema version. An
+// unnamed project is named after the file.
+func SaveProject(project *Project, path string) error {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	project.Version = ProjectVersion
+	project.Videos = nil
+	project.Modified = now
+	if project.Created.IsZero() {
+		project.Created = now
+	}
+	if project.Name == "" {
+		project.Name = projectNameFromPath(path)
+	}
+
+	// Write relative paths without changing the caller's clips
+	saved := *project
+	saved.Clips = make([]ProjectClip, len(project.Clips))
+	for i, clip := range project.Clips {
+		saved.Clips[i] = clip.relativeTo(filepath.Dir(path))
+	}
+	if project.Export.Music.Enabled() {
+		saved.Export.Music.Tracks = make([]MusicTrack, len(project.Export.Music.Tracks))
+		for i, track := range project.Export.Music.Tracks {
+			saved.Export.Music.Tracks[i].Path, saved.Export.Music.Tracks[i].AbsPath = relativePath(track.Path, filepath.Dir(path))
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -26,16 +145,163 @@ func SaveProject(videos []*Video, path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-func LoadProject(path string) ([]string, error) {
+// LoadProject reads a project file of any supported version and migrates
+// it to the current one.
+func LoadProject(path string) (*Project, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var project Project
-	if err := json.Unmarshal(data, &project); err != nil {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
 		return nil, err
 	}
+	if header.Version > ProjectVersion {
+		return nil, fmt.Errorf("project file version %d is newer than this version of Video Arranger supports (up to %d); please update the app to open it", header.Version, ProjectVersion)
+	}
+	if header.Version < 0 {
+		return nil, fmt.Errorf("invalid project file version %d", header.Version)
+	}
+
+	project := &Project{Selected: -1}
+	if err := json.Unmarshal(data, project); err != nil {
+		return nil, err
+	}
+
+	if project.Version < 2 {
+		migrateProjectV1(project)
+	}
+	for i, clip := range project.Clips {
+		project.Clips[i] = clip.resolve(filepath.Dir(path))
+	}
+	for i, track := range project.Export.Music.Tracks {
+		project.Export.Music.Tracks[i] = MusicTrack{Path: resolvePath(track.Path, track.AbsPath, filepath.Dir(path))}
+	}
+	if project.Name == "" {
+		project.Name = projectNameFromPath(path)
+	}
+	if project.Selected >= len(project.Clips) {
+		project.Selected = -1
+	}
+
+	return project, nil
+}
+
+// migrateProjectV1 converts a version 1 project, which stored only paths
+// and trim points, to version 2 with default settings.
+func migrateProjectV1(project *Project) {
+	if len(project.Clips) == 0 {
+		for _, videoPath := range project.Videos {
+			project.Clips = append(project.Clips, ProjectClip{Path: videoPath})
+		}
+	}
+
+	project.Version = ProjectVersion
+	project.Videos = nil
+	project.Selected = -1
+	project.Export = ExportOptions{TransitionDuration: 1.0}
+}
+
+// relativeTo returns the clip as written to a project file in dir.
+func (c ProjectClip) relativeTo(dir string) ProjectClip {
+	if c.Title == nil {
+		c.Path, c.AbsPath = relativePath(c.Path, dir)
+	}
+	return c
+}
+
+// resolve turns the paths of a clip read from a project file in dir into
+// an absolute Path.
+func (c ProjectClip) resolve(dir string) ProjectClip {
+	if c.Title == nil {
+		c.Path, c.AbsPath = resolvePath(c.Path, c.AbsPath, dir), ""
+	}
+	return c
+}
+
+// relativePath returns path as written to a project file in dir: relative
+// to dir where possible, with the absolute path as a fallback.
+func relativePath(path, dir string) (rel, abs string) {
+	if !filepath.IsAbs(path) {
+		return path, ""
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return path, ""
+	}
+	rel, err = filepath.Rel(absDir, path)
+	if err != nil {
+		// e.g. a different drive on Windows
+		return filepath.ToSlash(path), ""
+	}
+
+	return filepath.ToSlash(rel), filepath.ToSlash(path)
+}
+
+// resolvePath turns a path read from a project file in dir into an
+// absolute path, falling back to abs if the relative path doesn't exist. A
+// file that is missing in both places keeps the relative location, which
+// is where it is expected after moving the project.
+func resolvePath(path, abs, dir string) string {
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		if absDir, err := filepath.Abs(dir); err == nil {
+			dir = absDir
+		}
+		path = filepath.Join(dir, path)
+	}
+
+	if abs != "" {
+		if _, err := os.Stat(path); err != nil {
+			abs = filepath.FromSlash(abs)
+			if _, err := os.Stat(abs); err == nil {
+				path = abs
+			}
+		}
+	}
+
+	return path
+}
+
+func projectNameFromPath(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// NewVideo returns the clip's video: a title card, or a placeholder to be
+// loaded with AddPlaceholders, with the clip settings applied.
+func (c ProjectClip) NewVideo() (*Video, error) {
+	if c.Title != nil {
+		video := NewTitleCard(*c.Title)
+		video.TransitionOut = c.Transition
+		video.ChapterTitle = c.Chapter
+		return video, nil
+	}
+
+	video := NewPlaceholder(c.Path)
+	return video, c.Apply(video)
+}
+
+// Apply copies the clip settings onto a freshly loaded video. An offline
+// video keeps the size saved in the project so it can be relinked.
+func (c ProjectClip) Apply(video *Video) error {
+	if video.Status == VideoOffline && video.Size == 0 {
+		video.Size = c.Size
+	}
+	video.savedSource = Fingerprint{Size: c.Size, ModTime: c.ModTime, Hash: c.Hash}
+	video.TransitionOut = c.Transition
+	video.ChapterTitle = c.Chapter
+	video.Overlays = c.Overlays
+	if video.Status == VideoReady {
+		video.checkSource()
+	}
+	return video.SetTrim(secondsToDuration(c.InPoint), secondsToDuration(c.OutPoint))
+}
 
-	return project.Videos, nil
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
 }
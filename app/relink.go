@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// FindRelinks searches folder and its subfolders for the files of offline
+// videos, matching them by file name and, when the size is known, by size.
+// It returns the new path of every video found, in a single pass over the
+// folder.
+func FindRelinks(videos []*Video, folder string) (map[*Video]string, error) {
+	missing := make(map[string][]*Video)
+	for _, video := range videos {
+		if video.Status == VideoOffline {
+			name := strings.ToLower(filepath.Base(video.Path))
+			missing[name] = append(missing[name], video)
+		}
+	}
+
+	found := make(map[*Video]string)
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			// Skip unreadable folders rather than giving up
+			return nil
+		}
+
+		candidates := missing[strings.ToLower(d.Name())]
+		if len(candidates) == 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		for _, video := range candidates {
+			if _, ok := found[video]; ok {
+				continue
+			}
+			if video.Size > 0 && video.Size != info.Size() {
+				continue
+			}
+			found[video] = path
+		}
+		return nil
+	})
+
+	return found, err
+}
+
+// Relink points videos at new files as a single undo step, keeping their
+// clip settings, and loads the new files in the background like
+// AddPlaceholders.
+func (s *State) Relink(ctx context.Context, relinks map[*Video]string, concurrency int, onDone func(results []ImportResult)) {
+	s.mu.Lock()
+	videos := s.cloneVideos()
+	sel := s.currentSelection()
+
+	var replaced []*Video
+	for i, video := range videos {
+		path, ok := relinks[video]
+		if !ok {
+			continue
+		}
+
+		placeholder := NewPlaceholder(path)
+		placeholder.copySettings(video)
+		videos[i] = placeholder
+		replaced = append(replaced, placeholder)
+
+		if sel.set[video] {
+			delete(sel.set, video)
+			sel.set[placeholder] = true
+		}
+	}
+
+	if len(replaced) == 0 {
+		s.mu.Unlock()
+		return
+	}
+
+	s.changeList(videos, sel)
+	s.mu.Unlock()
+
+	s.notifyChange()
+	s.loadInBackground(ctx, replaced, concurrency, onDone)
+}
@@ -0,0 +1,175 @@
+package app
+
+import "maps"
+
+// selectionState is a snapshot of the selection, stored with list changes
+// so undo and redo restore it.
+type selectionState struct {
+	primary int
+	set     map[*Video]bool
+}
+
+func singleSelection(videos []*Video, index int) selectionState {
+	sel := selectionState{primary: -1, set: make(map[*Video]bool)}
+	if index >= 0 && index < len(videos) {
+		sel.primary = index
+		sel.set[videos[index]] = true
+	}
+	return sel
+}
+
+// currentSelection returns a copy of the selection. The caller holds the lock.
+func (s *State) currentSelection() selectionState {
+	return selectionState{primary: s.selected, set: maps.Clone(s.selection)}
+}
+
+// restoreSelection applies a snapshot, dropping clips that are no longer
+// in the list. The caller holds the lock.
+func (s *State) restoreSelection(sel selectionState) {
+	s.selection = make(map[*Video]bool)
+	for _, video := range s.videos {
+		if sel.set[video] {
+			s.selection[video] = true
+		}
+	}
+
+	s.selected = sel.primary
+	if s.selected >= len(s.videos) {
+		s.selected = len(s.videos) - 1
+	}
+}
+
+// selectedIndices returns the positions of all selected clips in ascending
+// order. The caller holds the lock.
+func (s *State) selectedIndices() []int {
+	var indices []int
+	for i, video := range s.videos {
+		if s.selection[video] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// SetSelected selects a single clip, or clears the selection for -1.
+func (s *State) SetSelected(index int) {
+	s.mu.Lock()
+	sel := singleSelection(s.videos, index)
+	s.selected = sel.primary
+	s.selection = sel.set
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// SetSelection selects the clips at indices with primary as the primary
+// selection, e.g. to restore a saved project. Out of range indices are
+// ignored.
+func (s *State) SetSelection(primary int, indices []int) {
+	s.mu.Lock()
+	sel := singleSelection(s.videos, primary)
+	for _, i := range indices {
+		if i >= 0 && i < len(s.videos) {
+			sel.set[s.videos[i]] = true
+		}
+	}
+	s.selected = sel.primary
+	s.selection = sel.set
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// ToggleSelect adds or removes a clip from the selection, as on a
+// Ctrl/Cmd-click.
+func (s *State) ToggleSelect(index int) {
+	s.mu.Lock()
+	if index < 0 || index >= len(s.videos) {
+		s.mu.Unlock()
+		return
+	}
+
+	video := s.videos[index]
+	if s.selection[video] {
+		delete(s.selection, video)
+		if s.selected == index {
+			s.selected = -1
+			if indices := s.selectedIndices(); len(indices) > 0 {
+				s.selected = indices[0]
+			}
+		}
+	} else {
+		s.selection[video] = true
+		s.selected = index
+	}
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// SelectRange selects every clip between from and to inclusive, as on a
+// Shift-click. The primary selection stays at from so the range can be
+// extended again.
+func (s *State) SelectRange(from, to int) {
+	s.mu.Lock()
+	if from < 0 || from >= len(s.videos) {
+		from = to
+	}
+	if to < 0 || to >= len(s.videos) {
+		s.mu.Unlock()
+		return
+	}
+
+	lo, hi := min(from, to), max(from, to)
+	s.selection = make(map[*Video]bool)
+	for _, video := range s.videos[lo : hi+1] {
+		s.selection[video] = true
+	}
+	s.selected = from
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+func (s *State) SelectAll() {
+	s.mu.Lock()
+	for _, video := range s.videos {
+		s.selection[video] = true
+	}
+	if s.selected < 0 && len(s.videos) > 0 {
+		s.selected = 0
+	}
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// GetSelected returns the primary selected clip, or -1.
+func (s *State) GetSelected() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.selected
+}
+
+// GetSelection returns the indices of all selected clips in ascending order.
+func (s *State) GetSelection() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.selectedIndices()
+}
+
+func (s *State) IsSelected(index int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return index >= 0 && index < len(s.videos) && s.selection[s.videos[index]]
+}
+
+func (s *State) SelectionCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.selection)
+}
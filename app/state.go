@@ -1,22 +1,35 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 )
 
 type State struct {
-	mu       sync.RWMutex
-	videos   []*Video
-	selected int
-	onChange func()
+	mu        sync.RWMutex
+	videos    []*Video
+	selected  int // primary selection: shown in the preview and anchor for range selection
+	selection map[*Video]bool
+	undoStack []command
+	redoStack []command
+	onChange  func()
+	settings  ProjectSettings
+
+	// Background loading of placeholders, see AddPlaceholders
+	importDone    int
+	importTotal   int
+	onVideoChange func(index int)
 }
 
 func NewState() *State {
 	return &State{
-		videos:   make([]*Video, 0),
-		selected: -1,
+		videos:    make([]*Video, 0),
+		selected:  -1,
+		selection: make(map[*Video]bool),
+		settings:  ProjectSettings{Export: ExportOptions{TransitionDuration: 1.0}},
 	}
 }
 
@@ -30,119 +43,302 @@ func (s *State) notifyChange() {
 	}
 }
 
+// Settings returns the project name, creation time and export options.
+func (s *State) Settings() ProjectSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.settings
+}
+
+// SetSettings replaces the project-wide settings. They are not part of the
+// undo history.
+func (s *State) SetSettings(settings ProjectSettings) {
+	s.mu.Lock()
+	s.settings = settings
+	s.mu.Unlock()
+
+	// The list shows the default transition between clips
+	s.notifyChange()
+}
+
+// Project captures the clip list, selection and settings for saving.
+func (s *State) Project() *Project {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return NewProject(s.videos, s.selected, s.selectedIndices(), s.settings)
+}
+
+// AddVideo adds a placeholder for path right away and loads its metadata
+// and thumbnail in the background.
 func (s *State) AddVideo(path string) error {
-	video, err := NewVideo(path)
-	if err != nil {
-		return err
+	video := NewPlaceholder(path)
+	if video.Status != VideoProbing {
+		return video.LoadErr
+	}
+
+	s.AddPlaceholders(context.Background(), []*Video{video}, 1, nil)
+	return nil
+}
+
+func (s *State) AppendVideo(video *Video) {
+	s.AppendVideos([]*Video{video})
+}
+
+// AppendVideos adds several clips at the end as a single undo step.
+func (s *State) AppendVideos(videos []*Video) {
+	if len(videos) == 0 {
+		return
 	}
 
 	s.mu.Lock()
-	s.videos = append(s.videos, video)
+	s.changeList(append(s.cloneVideos(), videos...), s.currentSelection())
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// InsertVideo adds a clip at index and selects it as a single undo step.
+// Title cards get their thumbnail rendered in the background.
+func (s *State) InsertVideo(index int, video *Video) {
+	s.mu.Lock()
+	index = min(max(index, 0), len(s.videos))
+	videos := slices.Insert(s.cloneVideos(), index, video)
+	s.changeList(videos, singleSelection(videos, index))
+	s.mu.Unlock()
+
+	if video.IsTitle() {
+		go s.renderTitleThumbnail(video)
+	}
+	s.notifyChange()
+}
+
+func (s *State) SetTrim(index int, in, out time.Duration) error {
+	s.mu.Lock()
+	if index < 0 || index >= len(s.videos) {
+		s.mu.Unlock()
+		return fmt.Errorf("no video selected")
+	}
+
+	// Validate on a copy so a rejected trim leaves no history entry
+	video := s.videos[index]
+	trimmed := *video
+	if err := trimmed.SetTrim(in, out); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	s.execute(&trimCommand{
+		video:  video,
+		oldIn:  video.InPoint,
+		oldOut: video.OutPoint,
+		newIn:  trimmed.InPoint,
+		newOut: trimmed.OutPoint,
+	})
 	s.mu.Unlock()
 
 	s.notifyChange()
 	return nil
 }
 
-func (s *State) RemoveSelected() {
+// SetTransition sets the transition at the boundary after the clip at
+// index, or restores the export default for nil.
+func (s *State) SetTransition(index int, transition *Transition) {
 	s.mu.Lock()
-	if s.selected < 0 || s.selected >= len(s.videos) {
+	if index < 0 || index >= len(s.videos) || sameTransition(s.videos[index].TransitionOut, transition) {
 		s.mu.Unlock()
 		return
 	}
 
-	s.videos = append(s.videos[:s.selected], s.videos[s.selected+1:]...)
+	video := s.videos[index]
+	s.execute(&transitionCommand{video: video, old: video.TransitionOut, new: transition})
+	s.mu.Unlock()
+
+	s.notifyChange()
+}
+
+// sameTransition reports whether two clip transitions are equal, where nil
+// is the export default.
+func sameTransition(a, b *Transition) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
 
-	if s.selected >= len(s.videos) {
-		s.selected = len(s.videos) - 1
+// SetChapterTitle overrides the chapter title of the clip at index; an
+// empty title uses the clip name.
+func (s *State) SetChapterTitle(index int, title string) {
+	s.mu.Lock()
+	if index < 0 || index >= len(s.videos) || s.videos[index].ChapterTitle == title {
+		s.mu.Unlock()
+		return
 	}
+
+	video := s.videos[index]
+	s.execute(&chapterCommand{video: video, old: video.ChapterTitle, new: title})
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
-func (s *State) MoveUp() {
+// SetTitle changes the title card at index.
+func (s *State) SetTitle(index int, card TitleCard) error {
 	s.mu.Lock()
-	if s.selected <= 0 || s.selected >= len(s.videos) {
+	if index < 0 || index >= len(s.videos) || !s.videos[index].IsTitle() {
 		s.mu.Unlock()
-		return
+		return fmt.Errorf("no title card selected")
 	}
 
-	s.videos[s.selected], s.videos[s.selected-1] = s.videos[s.selected-1], s.videos[s.selected]
-	s.selected--
+	video := s.videos[index]
+	s.execute(&titleCommand{video: video, old: *video.Title, new: card})
 	s.mu.Unlock()
 
 	s.notifyChange()
+	return nil
 }
 
-func (s *State) MoveDown() {
+// SetOverlays replaces the text overlays of the clip at index.
+func (s *State) SetOverlays(index int, overlays []TextOverlay) {
 	s.mu.Lock()
-	if s.selected < 0 || s.selected >= len(s.videos)-1 {
+	if index < 0 || index >= len(s.videos) {
 		s.mu.Unlock()
 		return
 	}
 
-	s.videos[s.selected], s.videos[s.selected+1] = s.videos[s.selected+1], s.videos[s.selected]
-	s.selected++
+	video := s.videos[index]
+	s.execute(&overlaysCommand{video: video, old: video.Overlays, new: overlays})
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
-func (s *State) MoveToTop() {
+func (s *State) RemoveSelected() {
 	s.mu.Lock()
-	if s.selected <= 0 || s.selected >= len(s.videos) {
+	indices := s.selectedIndices()
+	if len(indices) == 0 {
 		s.mu.Unlock()
 		return
 	}
 
-	video := s.videos[s.selected]
-	s.videos = append(s.videos[:s.selected], s.videos[s.selected+1:]...)
-	s.videos = append([]*Video{video}, s.videos...)
-	s.selected = 0
+	videos := make([]*Video, 0, len(s.videos))
+	for _, video := range s.videos {
+		if !s.selection[video] {
+			videos = append(videos, video)
+		}
+	}
+
+	// Select the clip that took the place of the first removed one
+	selected := indices[0]
+	if selected >= len(videos) {
+		selected = len(videos) - 1
+	}
+	s.changeList(videos, singleSelection(videos, selected))
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
-func (s *State) MoveToBottom() {
+func (s *State) MoveUp() {
 	s.mu.Lock()
-	if s.selected < 0 || s.selected >= len(s.videos)-1 {
+	indices := s.selectedIndices()
+	if len(indices) == 0 || indices[0] == 0 {
 		s.mu.Unlock()
 		return
 	}
 
-	video := s.videos[s.selected]
-	s.videos = append(s.videos[:s.selected], s.videos[s.selected+1:]...)
-	s.videos = append(s.videos, video)
-	s.selected = len(s.videos) - 1
+	s.moveSelection(indices[0] - 1)
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
-func (s *State) Clear() {
+func (s *State) MoveDown() {
 	s.mu.Lock()
-	s.videos = make([]*Video, 0)
-	s.selected = -1
+	indices := s.selectedIndices()
+	if len(indices) == 0 || indices[len(indices)-1] >= len(s.videos)-1 {
+		s.mu.Unlock()
+		return
+	}
+
+	s.moveSelection(indices[len(indices)-1] + 2)
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
-func (s *State) SetSelected(index int) {
+func (s *State) MoveToTop() {
+	s.MoveSelection(0)
+}
+
+func (s *State) MoveToBottom() {
+	s.MoveSelection(s.Count())
+}
+
+// MoveSelection moves all selected clips, as one block in their current
+// order, so that they are inserted before the clip at index to. Passing
+// Count() moves them to the end.
+func (s *State) MoveSelection(to int) {
 	s.mu.Lock()
-	s.selected = index
+	if len(s.selection) == 0 || to < 0 || to > len(s.videos) {
+		s.mu.Unlock()
+		return
+	}
+
+	if !s.moveSelection(to) {
+		s.mu.Unlock()
+		return
+	}
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
-func (s *State) GetSelected() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// moveSelection implements MoveSelection and reports whether the order
+// changed. The caller holds the lock.
+func (s *State) moveSelection(to int) bool {
+	var block, rest []*Video
+	insertAt := to
+	for i, video := range s.videos {
+		if s.selection[video] {
+			block = append(block, video)
+			if i < to {
+				insertAt--
+			}
+		} else {
+			rest = append(rest, video)
+		}
+	}
+
+	videos := make([]*Video, 0, len(s.videos))
+	videos = append(videos, rest[:insertAt]...)
+	videos = append(videos, block...)
+	videos = append(videos, rest[insertAt:]...)
+
+	if slices.Equal(videos, s.videos) {
+		return false
+	}
+
+	sel := s.currentSelection()
+	if s.selected >= 0 && s.selected < len(s.videos) {
+		sel.primary = slices.Index(videos, s.videos[s.selected])
+	}
+	s.changeList(videos, sel)
+	return true
+}
+
+func (s *State) Clear() {
+	s.mu.Lock()
+	if len(s.videos) == 0 {
+		s.mu.Unlock()
+		return
+	}
 
-	return s.selected
+	s.changeList(make([]*Video, 0), selectionState{primary: -1})
+	s.mu.Unlock()
+
+	s.notifyChange()
 }
 
 func (s *State) GetVideos() []*Video {
@@ -154,6 +350,12 @@ func (s *State) GetVideos() []*Video {
 	return result
 }
 
+// cloneVideos returns a copy of the clip list to build a change on. The
+// caller holds the lock.
+func (s *State) cloneVideos() []*Video {
+	return append(make([]*Video, 0, len(s.videos)+1), s.videos...)
+}
+
 func (s *State) Count() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -168,8 +370,9 @@ func (s *State) Move(from, to int) {
 		return
 	}
 
-	video := s.videos[from]
-	s.videos = append(s.videos[:from], s.videos[from+1:]...)
+	videos := s.cloneVideos()
+	video := videos[from]
+	videos = append(videos[:from], videos[from+1:]...)
 
 	// Adjust target index since we removed an element before it
 	insertAt := to
@@ -177,27 +380,23 @@ func (s *State) Move(from, to int) {
 		insertAt = to - 1
 	}
 
-	newVideos := make([]*Video, 0, len(s.videos)+1)
-	newVideos = append(newVideos, s.videos[:insertAt]...)
+	newVideos := make([]*Video, 0, len(videos)+1)
+	newVideos = append(newVideos, videos[:insertAt]...)
 	newVideos = append(newVideos, video)
-	newVideos = append(newVideos, s.videos[insertAt:]...)
-	s.videos = newVideos
-
-	s.selected = insertAt
+	newVideos = append(newVideos, videos[insertAt:]...)
+	s.changeList(newVideos, singleSelection(newVideos, insertAt))
 	s.mu.Unlock()
 
 	s.notifyChange()
 }
 
+// TotalDuration returns the length of the export, with the clips
+// overlapping at their transitions.
 func (s *State) TotalDuration() time.Duration {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var total time.Duration
-	for _, video := range s.videos {
-		total += video.Duration
-	}
-	return total
+	return expectedDuration(s.videos, boundaryTransitions(s.videos, s.settings.Export))
 }
 
 func (s *State) TotalDurationString() string {
@@ -206,13 +405,5 @@ func (s *State) TotalDurationString() string {
 		return ""
 	}
 
-	totalSec := int(total.Seconds())
-	hours := totalSec / 3600
-	minutes := (totalSec % 3600) / 60
-	seconds := totalSec % 60
-
-	if hours > 0 {
-		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
-	}
-	return fmt.Sprintf("%d:%02d", minutes, seconds)
+	return FormatTimecode(total)
 }
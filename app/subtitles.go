@@ -0,0 +1,317 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SubtitleMode is how the clips' subtitles are written on export.
+type SubtitleMode string
+
+const (
+	SubtitlesOff     SubtitleMode = ""
+	SubtitlesTrack   SubtitleMode = "track"   // a soft subtitle track in the output
+	SubtitlesSidecar SubtitleMode = "sidecar" // an .srt file next to the output
+	SubtitlesBurn    SubtitleMode = "burn"    // drawn into the picture
+)
+
+// SubtitleModes lists the modes in the order they are offered.
+var SubtitleModes = []SubtitleMode{SubtitlesOff, SubtitlesTrack, SubtitlesSidecar, SubtitlesBurn}
+
+func (m SubtitleMode) String() string {
+	switch m {
+	case SubtitlesTrack:
+		return "Subtitle track"
+	case SubtitlesSidecar:
+		return "Sidecar file"
+	case SubtitlesBurn:
+		return "Burn in"
+	}
+	return "Off"
+}
+
+// ParseSubtitleMode accepts a mode by its id ("off", "track", "sidecar",
+// "burn") or its display name.
+func ParseSubtitleMode(name string) (SubtitleMode, error) {
+	name = strings.TrimSpace(name)
+	for _, m := range SubtitleModes {
+		if strings.EqualFold(name, string(m)) || strings.EqualFold(name, m.String()) {
+			return m, nil
+		}
+	}
+	return SubtitlesOff, fmt.Errorf("unknown subtitle mode %q (want off, track, sidecar or burn)", name)
+}
+
+// subtitleCue is a single subtitle: text shown from Start to End.
+type subtitleCue struct {
+	Start, End time.Duration
+	Text       string
+}
+
+// subtitleExtensions are the sidecar files looked for next to a clip, in
+// order of preference.
+var subtitleExtensions = []string{".srt", ".vtt"}
+
+// findSidecar returns the subtitle file next to a clip with the same base
+// name, e.g. "trip.srt" for "trip.mp4", or "" if there is none.
+func findSidecar(videoPath string) string {
+	base := strings.TrimSuffix(videoPath, filepath.Ext(videoPath))
+	for _, ext := range subtitleExtensions {
+		for _, candidate := range []string{base + ext, base + strings.ToUpper(ext)} {
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// loadClipSubtitles returns the subtitles of a clip and where they came
+// from: a sidecar file if there is one, otherwise the first embedded text
+// subtitle stream. A clip without subtitles returns no cues.
+func loadClipSubtitles(ctx context.Context, video *Video) ([]subtitleCue, string, error) {
+	if video.IsTitle() {
+		return nil, "", nil
+	}
+
+	if sidecar := findSidecar(video.Path); sidecar != "" {
+		data, err := os.ReadFile(sidecar)
+		if err != nil {
+			return nil, "", err
+		}
+		cues, err := parseSubtitles(data)
+		return cues, filepath.Base(sidecar), err
+	}
+
+	for _, stream := range video.Media.Subtitles {
+		if !stream.IsText() {
+			continue
+		}
+		cues, err := extractSubtitles(ctx, video.Path, stream.Index)
+		return cues, "embedded " + stream.String(), err
+	}
+	return nil, "", nil
+}
+
+// extractSubtitles converts an embedded subtitle stream to SubRip and
+// parses it.
+func extractSubtitles(ctx context.Context, path string, index int) ([]subtitleCue, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-i", path,
+		"-map", fmt.Sprintf("0:s:%d", index),
+		"-f", "srt",
+		"-")
+
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("ffmpeg error: %w\n%s", err, stderr.String())
+	}
+	return parseSubtitles(out.Bytes())
+}
+
+// parseSubtitles reads SubRip or WebVTT cues. Both are blocks separated by
+// blank lines, with a "start --> end" line followed by the text; numbers,
+// cue identifiers and WebVTT's header, NOTE and STYLE blocks are skipped.
+func parseSubtitles(data []byte) ([]subtitleCue, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	var cues []subtitleCue
+	for _, block := range strings.Split(string(data), "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+
+		startText, endText, _ := strings.Cut(lines[timing], "-->")
+		start, err := parseCueTime(startText)
+		if err != nil {
+			return nil, err
+		}
+		// WebVTT cue settings follow the end time
+		endFields := strings.Fields(endText)
+		if len(endFields) == 0 {
+			return nil, fmt.Errorf("invalid subtitle timing %q", lines[timing])
+		}
+		end, err := parseCueTime(endFields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		text := strings.TrimSpace(strings.Join(lines[timing+1:], "\n"))
+		if text != "" && end > start {
+			cues = append(cues, subtitleCue{Start: start, End: end, Text: text})
+		}
+	}
+	return cues, nil
+}
+
+// parseCueTime parses "hh:mm:ss,mmm" (SubRip) and "[hh:]mm:ss.mmm"
+// (WebVTT) timestamps.
+func parseCueTime(s string) (time.Duration, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid subtitle time %q", s)
+	}
+
+	var total float64
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid subtitle time %q", s)
+		}
+		total = total*60 + value
+	}
+	return secondsToDuration(total), nil
+}
+
+// formatSRT writes cues as a SubRip file.
+func formatSRT(cues []subtitleCue) []byte {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	for i, cue := range cues {
+		fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, formatCueTime(cue.Start), formatCueTime(cue.End), cue.Text)
+	}
+	w.Flush()
+	return b.Bytes()
+}
+
+func formatCueTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// gatherSubtitles collects the subtitles of all clips on the output
+// timeline. Each clip's cues are cut to its trim points and moved to where
+// the clip starts in the output, which accounts for transition overlaps
+// the same way as the chapters. It returns the cues and a line per clip
+// describing where its subtitles came from.
+func gatherSubtitles(ctx context.Context, videos []*Video, chapters []Chapter) ([]subtitleCue, []string, error) {
+	var merged []subtitleCue
+	var sources []string
+
+	for i, video := range videos {
+		cues, source, err := loadClipSubtitles(ctx, video)
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		if err != nil {
+			// A broken subtitle file shouldn't stop the export
+			log.Printf("Skipping subtitles of %s: %v", video.Name, err)
+			sources = append(sources, fmt.Sprintf("%s: skipped (%v)", video.Name, err))
+			continue
+		}
+		if len(cues) == 0 {
+			continue
+		}
+
+		in, out := video.InPoint, video.EffectiveOutPoint()
+		offset := chapters[i].Start - in
+		count := 0
+		for _, cue := range cues {
+			if cue.End <= in || cue.Start >= out {
+				continue
+			}
+			cue.Start = max(cue.Start, in) + offset
+			cue.End = min(cue.End, out) + offset
+			merged = append(merged, cue)
+			count++
+		}
+		sources = append(sources, fmt.Sprintf("%s: %d subtitles from %s", video.Name, count, source))
+	}
+
+	return merged, sources, nil
+}
+
+// subtitleCodec returns the subtitle format the output container takes,
+// or "" if it can't hold text subtitles.
+func subtitleCodec(outputPath string) string {
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".mp4", ".mov", ".m4v":
+		return "mov_text"
+	case ".mkv":
+		return "srt"
+	case ".webm":
+		return "webvtt"
+	}
+	return ""
+}
+
+// sidecarSubtitlesPath returns where subtitles are written next to an
+// export, e.g. "trip.srt" for "trip.mp4".
+func sidecarSubtitlesPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".srt"
+}
+
+// prepareSubtitles merges the clips' subtitles for an export. It returns
+// the mode actually used, which is off when no clip has subtitles and a
+// sidecar file when the output container can't hold a subtitle track, the
+// merged subtitles, and for a track or burn-in the temporary file holding
+// them, which the caller removes.
+func prepareSubtitles(ctx context.Context, mode SubtitleMode, videos []*Video, chapters []Chapter, outputPath string, progress chan<- ExportProgress) (SubtitleMode, []byte, string, error) {
+	if mode == SubtitlesOff {
+		return mode, nil, "", nil
+	}
+	if mode == SubtitlesTrack && subtitleCodec(outputPath) == "" {
+		mode = SubtitlesSidecar
+	}
+
+	progress <- ExportProgress{Status: "Collecting subtitles..."}
+	cues, sources, err := gatherSubtitles(ctx, videos, chapters)
+	if err != nil {
+		return SubtitlesOff, nil, "", err
+	}
+	if len(cues) == 0 {
+		progress <- ExportProgress{Status: "No clip has subtitles, exporting without them"}
+		return SubtitlesOff, nil, "", nil
+	}
+
+	data := formatSRT(cues)
+	progress <- ExportProgress{
+		Status: fmt.Sprintf("Merged %d subtitles", len(cues)),
+		Detail: "Subtitles:\n" + strings.Join(sources, "\n"),
+	}
+	if mode == SubtitlesSidecar {
+		return mode, data, "", nil
+	}
+
+	f, err := os.CreateTemp("", "video-subtitles-*.srt")
+	if err != nil {
+		return SubtitlesOff, nil, "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return SubtitlesOff, nil, "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return SubtitlesOff, nil, "", err
+	}
+	return mode, data, f.Name(), nil
+}
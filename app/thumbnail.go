@@ -6,10 +6,30 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 	"os/exec"
+	"time"
 )
 
+// LoadThumbnail returns the cached thumbnail of a file, extracting and
+// caching it on a miss.
+func LoadThumbnail(path string, size int64, modTime time.Time) (image.Image, error) {
+	cache := DefaultMediaCache()
+	if thumb, ok := cache.LoadThumbnail(path, size, modTime); ok {
+		return thumb, nil
+	}
+
+	thumb, err := ExtractThumbnail(path)
+	if err != nil {
+		return nil, err
+	}
+	cache.StoreThumbnail(path, size, modTime, thumb)
+	return thumb, nil
+}
+
+// ExtractThumbnail grabs the first frame of a video. ffmpeg applies the
+// rotation metadata before the scale filter, so phone clips come out upright.
 func ExtractThumbnail(videoPath string) (image.Image, error) {
 	cmd := exec.Command("ffmpeg",
+		"-autorotate",
 		"-i", videoPath,
 		"-vframes", "1",
 		"-f", "image2pipe",
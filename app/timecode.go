@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatTimecode formats a duration as m:ss or h:mm:ss.
+func FormatTimecode(d time.Duration) string {
+	total := int(d.Seconds())
+	hours := total / 3600
+	minutes := (total % 3600) / 60
+	seconds := total % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
+
+// FormatTimecodePrecise formats a duration like FormatTimecode but keeps
+// tenths of a second, e.g. 1:02.5.
+func FormatTimecodePrecise(d time.Duration) string {
+	tenths := int(d.Round(100*time.Millisecond) / (100 * time.Millisecond))
+	return fmt.Sprintf("%s.%d", FormatTimecode(time.Duration(tenths/10)*time.Second), tenths%10)
+}
+
+// ParseTimecode parses plain seconds ("12.5"), m:ss(.f) or h:mm:ss(.f).
+func ParseTimecode(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timecode %q", s)
+	}
+
+	var seconds float64
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid timecode %q", s)
+		}
+		seconds = seconds*60 + value
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
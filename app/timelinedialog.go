@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// OnExportEDL writes the clip order, trims and transitions to a CMX3600
+// EDL for finishing in another editing system.
+func (h *Handlers) OnExportEDL() {
+	h.exportTimeline("Export EDL", ".edl", ExportEDL)
+}
+
+// OnExportFCPXML writes the clips and their transitions to an FCPXML
+// project for Final Cut Pro or DaVinci Resolve.
+func (h *Handlers) OnExportFCPXML() {
+	h.exportTimeline("Export FCPXML", ".fcpxml", ExportFCPXML)
+}
+
+// exportTimeline asks where to save the timeline in another editing
+// system's format and writes it there with export, under the project
+// name.
+func (h *Handlers) exportTimeline(title, ext string, export func(path, name string, videos []*Video, options ExportOptions) error) {
+	videos := h.state.GetVideos()
+	if len(videos) == 0 {
+		dialog.ShowInformation(title, "No videos to export. Add some videos first.", h.window)
+		return
+	}
+	for _, video := range videos {
+		if video.Status == VideoProbing {
+			dialog.ShowInformation(title, video.Name+" is still loading.", h.window)
+			return
+		}
+	}
+
+	settings := h.state.Settings()
+	fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, h.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+
+		outputPath := writer.URI().Path()
+		writer.Close()
+
+		name := settings.Name
+		if name == "" {
+			name = projectNameFromPath(outputPath)
+		}
+		if err := export(outputPath, name, videos, settings.Export); err != nil {
+			os.Remove(outputPath)
+			dialog.ShowError(err, h.window)
+			return
+		}
+		dialog.ShowInformation(title, "Timeline written to:\n"+outputPath, h.window)
+	}, h.window)
+
+	name := "timeline"
+	if settings.Name != "" {
+		name = settings.Name
+	}
+	fd.SetFileName(name + ext)
+	fd.Show()
+}
+
+// OnImportEDL rebuilds the project from a CMX3600 EDL, looking up its clips
+// in a folder chosen after the EDL.
+func (h *Handlers) OnImportEDL() {
+	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, h.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+
+		path := reader.URI().Path()
+		reader.Close()
+
+		folder := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, h.window)
+				return
+			}
+			if uri == nil {
+				return
+			}
+
+			project, err := ImportEDL(path, uri.Path(), 0)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to import %s: %w", filepath.Base(path), err), h.window)
+				return
+			}
+			h.openProject(project)
+		}, h.window)
+		folder.SetConfirmText("Use Media Folder")
+		folder.Show()
+	}, h.window)
+
+	fd.SetFilter(&edlFilter{})
+	fd.Show()
+}
+
+type edlFilter struct{}
+
+func (f *edlFilter) Matches(uri fyne.URI) bool {
+	return strings.ToLower(filepath.Ext(uri.Path())) == ".edl"
+}
+
+func (f *edlFilter) Extensions() []string {
+	return []string{".edl"}
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// referenceHeight is the frame height that font sizes are given for. Text
+// is scaled with the output so a title looks the same at any resolution.
+const referenceHeight = 1080
+
+// TextStyle is the look of title card text and text overlays.
+type TextStyle struct {
+	Font     string `json:"font,omitempty"` // fontconfig name or path to a font file, empty for the default
+	FontSize int    `json:"fontSize"`       // in pixels at 1080p
+	Color    string `json:"color"`          // ffmpeg color, e.g. "white" or "#ffcc00"
+}
+
+// TitleCard describes a generated clip with text on a solid or gradient
+// background.
+type TitleCard struct {
+	Text string `json:"text"`
+	TextStyle
+	Background string  `json:"background"`         // ffmpeg color
+	Gradient   string  `json:"gradient,omitempty"` // bottom color of a vertical gradient, empty for solid
+	Duration   float64 `json:"duration"`           // in seconds
+}
+
+// TextOverlay draws text over part of a clip. Times are in seconds from the
+// start of the trimmed clip; a zero End lasts to the end of the clip.
+type TextOverlay struct {
+	Text string `json:"text"`
+	TextStyle
+	Start    float64 `json:"start,omitempty"`
+	End      float64 `json:"end,omitempty"`
+	Position string  `json:"position,omitempty"` // "top", "center" or "bottom" (the default)
+}
+
+// OverlayPositions are the positions a text overlay can be placed at.
+var OverlayPositions = []string{"top", "center", "bottom"}
+
+// DefaultTitleCard returns the settings a new title card starts with.
+func DefaultTitleCard() TitleCard {
+	return TitleCard{
+		TextStyle:  TextStyle{FontSize: 96, Color: "white"},
+		Background: "black",
+		Duration:   3,
+	}
+}
+
+// DefaultTextOverlay returns the settings a new text overlay starts with.
+func DefaultTextOverlay() TextOverlay {
+	return TextOverlay{
+		TextStyle: TextStyle{FontSize: 64, Color: "white"},
+		Position:  "bottom",
+	}
+}
+
+// NewTitleCard returns a clip for a title card. It has no source file; its
+// frames are generated by ffmpeg when exporting.
+func NewTitleCard(card TitleCard) *Video {
+	video := &Video{}
+	video.setTitle(card)
+	return video
+}
+
+func (v *Video) setTitle(card TitleCard) {
+	if card.Duration <= 0 {
+		card.Duration = DefaultTitleCard().Duration
+	}
+
+	name, _, _ := strings.Cut(strings.TrimSpace(card.Text), "\n")
+	if name == "" {
+		name = "Untitled"
+	}
+
+	v.Title = &card
+	v.Name = "Title: " + name
+	v.Duration = secondsToDuration(card.Duration)
+	v.Width, v.Height = 0, 0
+	v.InPoint, v.OutPoint = 0, 0
+}
+
+// IsTitle reports whether the clip is a generated title card.
+func (v *Video) IsTitle() bool {
+	return v.Title != nil
+}
+
+// titleSource returns the lavfi graph that generates a title card at the
+// given size and frame rate.
+func titleSource(card TitleCard, width, height int, frameRate float64) string {
+	var background string
+	if card.Gradient != "" {
+		// gradients always rotates; at its minimum speed the angle moves
+		// by a fraction of a degree over a title's duration
+		background = fmt.Sprintf("gradients=s=%dx%d:r=%.3f:d=%.3f:c0=%s:c1=%s:nb_colors=2:x0=%d:y0=0:x1=%d:y1=%d:speed=0.00001",
+			width, height, frameRate, card.Duration, filterQuote(card.Background), filterQuote(card.Gradient), width/2, width/2, height)
+	} else {
+		background = fmt.Sprintf("color=c=%s:s=%dx%d:r=%.3f:d=%.3f",
+			filterQuote(card.Background), width, height, frameRate, card.Duration)
+	}
+
+	if strings.TrimSpace(card.Text) == "" {
+		return background + ",format=yuv420p"
+	}
+	return background + "," + drawText(card.Text, card.TextStyle, height, "(w-text_w)/2", "(h-text_h)/2", "") + ",format=yuv420p"
+}
+
+// overlayFilters returns the drawtext filters for a clip's text overlays,
+// joined for appending to a filter chain, or "" if it has none.
+func overlayFilters(video *Video, height int) string {
+	var filters []string
+	for _, overlay := range video.Overlays {
+		if strings.TrimSpace(overlay.Text) == "" {
+			continue
+		}
+
+		y := "h-text_h-h/10"
+		switch overlay.Position {
+		case "top":
+			y = "h/10"
+		case "center":
+			y = "(h-text_h)/2"
+		}
+
+		enable := ""
+		if overlay.End > overlay.Start {
+			enable = fmt.Sprintf("between(t,%.3f,%.3f)", overlay.Start, overlay.End)
+		} else if overlay.Start > 0 {
+			enable = fmt.Sprintf("gte(t,%.3f)", overlay.Start)
+		}
+
+		filters = append(filters, drawText(overlay.Text, overlay.TextStyle, height, "(w-text_w)/2", y, enable))
+	}
+	return strings.Join(filters, ",")
+}
+
+// drawText returns a drawtext filter for text in style, with the font
+// scaled to a frame of the given height.
+func drawText(text string, style TextStyle, height int, x, y, enable string) string {
+	size := style.FontSize
+	if size <= 0 {
+		size = DefaultTextOverlay().FontSize
+	}
+	size = max(size*height/referenceHeight, 1)
+
+	color := style.Color
+	if color == "" {
+		color = "white"
+	}
+
+	args := []string{
+		"expansion=none",
+		"text=" + filterQuote(text),
+		fmt.Sprintf("fontsize=%d", size),
+		"fontcolor=" + filterQuote(color),
+		"line_spacing=" + fmt.Sprint(size/4),
+		"shadowx=2", "shadowy=2",
+		"x=" + x,
+		"y=" + y,
+	}
+
+	switch font := style.Font; {
+	case font == "":
+	case strings.ContainsAny(font, `/\`) || isFontFile(font):
+		args = append(args, "fontfile="+filterQuote(filepath.ToSlash(font)))
+	default:
+		args = append(args, "font="+filterQuote(font))
+	}
+
+	if enable != "" {
+		args = append(args, "enable="+filterQuote(enable))
+	}
+
+	return "drawtext=" + strings.Join(args, ":")
+}
+
+func isFontFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ttf", ".otf", ".ttc":
+		return true
+	}
+	return false
+}
+
+// filterQuote escapes a filter option value for a filter graph. Values go
+// through two rounds of unescaping in ffmpeg: once when the graph is split
+// into filters and once when a filter's options are parsed.
+func filterQuote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(value)
+}
+
+// RenderTitleThumbnail renders the middle frame of a title card at list
+// thumbnail size.
+func RenderTitleThumbnail(card TitleCard) (image.Image, error) {
+	cmd := exec.Command("ffmpeg",
+		"-v", "error",
+		"-f", "lavfi",
+		"-i", titleSource(card, 320, 180, 1),
+		"-ss", fmt.Sprintf("%.3f", card.Duration/2),
+		"-frames:v", "1",
+		"-f", "image2pipe",
+		"-vcodec", "png",
+		"-")
+
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %w\n%s", err, stderr.String())
+	}
+	return png.Decode(&out)
+}
@@ -0,0 +1,276 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// OnAddTitle adds a title card after the selected clip, or at the end if
+// nothing is selected.
+func (h *Handlers) OnAddTitle() {
+	h.showTitleDialog("Add Title", DefaultTitleCard(), func(card TitleCard) {
+		at := h.state.Count()
+		if selected := h.state.GetSelected(); selected >= 0 {
+			at = selected + 1
+		}
+		h.state.InsertVideo(at, NewTitleCard(card))
+	})
+}
+
+// OnEditText edits the selected title card, or the text overlays of the
+// selected video clip.
+func (h *Handlers) OnEditText() {
+	index := h.state.GetSelected()
+	videos := h.state.GetVideos()
+	if index < 0 || index >= len(videos) {
+		return
+	}
+
+	video := videos[index]
+	if video.IsTitle() {
+		h.showTitleDialog("Edit Title", *video.Title, func(card TitleCard) {
+			if err := h.state.SetTitle(index, card); err != nil {
+				dialog.ShowError(err, h.window)
+			}
+		})
+		return
+	}
+	h.showOverlaysDialog(index, video)
+}
+
+func (h *Handlers) showTitleDialog(title string, card TitleCard, onSave func(TitleCard)) {
+	textEntry := widget.NewMultiLineEntry()
+	textEntry.SetText(card.Text)
+	textEntry.SetPlaceHolder("Title text")
+	textEntry.SetMinRowsVisible(3)
+
+	style := newStyleFields(card.TextStyle)
+
+	backgroundEntry := widget.NewEntry()
+	backgroundEntry.SetText(card.Background)
+	gradientEntry := widget.NewEntry()
+	gradientEntry.SetText(card.Gradient)
+	gradientEntry.SetPlaceHolder("None (solid background)")
+
+	durationEntry := widget.NewEntry()
+	durationEntry.SetText(strconv.FormatFloat(card.Duration, 'f', -1, 64))
+
+	form := widget.NewForm(widget.NewFormItem("Text", textEntry))
+	for _, item := range style.items() {
+		form.AppendItem(item)
+	}
+	form.Append("Background", backgroundEntry)
+	form.Append("Gradient to", gradientEntry)
+	form.Append("Duration (sec)", durationEntry)
+
+	d := dialog.NewCustomConfirm(title, "OK", "Cancel", form, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		edited := TitleCard{
+			Text:       textEntry.Text,
+			Background: strings.TrimSpace(backgroundEntry.Text),
+			Gradient:   strings.TrimSpace(gradientEntry.Text),
+		}
+		var err error
+		if edited.TextStyle, err = style.read(); err != nil {
+			dialog.ShowError(err, h.window)
+			return
+		}
+		if edited.Background == "" {
+			edited.Background = DefaultTitleCard().Background
+		}
+		if edited.Duration, err = strconv.ParseFloat(strings.TrimSpace(durationEntry.Text), 64); err != nil || edited.Duration <= 0 {
+			dialog.ShowError(fmt.Errorf("invalid duration %q", durationEntry.Text), h.window)
+			return
+		}
+		onSave(edited)
+	}, h.window)
+	d.Resize(fyne.NewSize(450, 0))
+	d.Show()
+}
+
+// showOverlaysDialog edits the list of text overlays on a video clip.
+func (h *Handlers) showOverlaysDialog(index int, video *Video) {
+	type overlayRow struct {
+		text     *widget.Entry
+		start    *widget.Entry
+		end      *widget.Entry
+		position *widget.Select
+		style    *styleFields
+	}
+
+	var rows []*overlayRow
+	list := container.NewVBox()
+
+	var rebuild func()
+	rebuild = func() {
+		list.RemoveAll()
+		for i, row := range rows {
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				rows = slices.DeleteFunc(rows, func(r *overlayRow) bool { return r == row })
+				rebuild()
+			})
+			remove.Importance = widget.LowImportance
+
+			form := widget.NewForm(
+				widget.NewFormItem("Text", row.text),
+				widget.NewFormItem("Start (sec)", row.start),
+				widget.NewFormItem("End (sec)", row.end),
+				widget.NewFormItem("Position", row.position),
+			)
+			for _, item := range row.style.items() {
+				form.AppendItem(item)
+			}
+
+			header := widget.NewLabel(fmt.Sprintf("Overlay %d", i+1))
+			header.TextStyle = fyne.TextStyle{Bold: true}
+			list.Add(container.NewBorder(nil, nil, header, remove))
+			list.Add(form)
+			list.Add(widget.NewSeparator())
+		}
+		list.Refresh()
+	}
+
+	addRow := func(overlay TextOverlay) {
+		row := &overlayRow{
+			text:     widget.NewEntry(),
+			start:    widget.NewEntry(),
+			end:      widget.NewEntry(),
+			position: widget.NewSelect(OverlayPositions, nil),
+			style:    newStyleFields(overlay.TextStyle),
+		}
+		row.text.SetText(overlay.Text)
+		row.start.SetText(strconv.FormatFloat(overlay.Start, 'f', -1, 64))
+		row.end.SetPlaceHolder("End of clip")
+		if overlay.End > 0 {
+			row.end.SetText(strconv.FormatFloat(overlay.End, 'f', -1, 64))
+		}
+		row.position.SetSelected(overlay.Position)
+		if row.position.Selected == "" {
+			row.position.SetSelected("bottom")
+		}
+		rows = append(rows, row)
+		rebuild()
+	}
+
+	for _, overlay := range video.Overlays {
+		addRow(overlay)
+	}
+
+	addButton := widget.NewButtonWithIcon("Add overlay", theme.ContentAddIcon(), func() {
+		addRow(DefaultTextOverlay())
+	})
+
+	content := container.NewBorder(nil, addButton, nil, nil, container.NewVScroll(list))
+
+	d := dialog.NewCustomConfirm("Text overlays on "+video.Name, "OK", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		var overlays []TextOverlay
+		for i, row := range rows {
+			overlay := TextOverlay{
+				Text:     row.text.Text,
+				Position: row.position.Selected,
+			}
+			if strings.TrimSpace(overlay.Text) == "" {
+				continue
+			}
+
+			var err error
+			if overlay.TextStyle, err = row.style.read(); err != nil {
+				dialog.ShowError(fmt.Errorf("overlay %d: %w", i+1, err), h.window)
+				return
+			}
+			if overlay.Start, err = parseSecondsEntry(row.start.Text); err != nil {
+				dialog.ShowError(fmt.Errorf("overlay %d: invalid start time %q", i+1, row.start.Text), h.window)
+				return
+			}
+			if overlay.End, err = parseSecondsEntry(row.end.Text); err != nil {
+				dialog.ShowError(fmt.Errorf("overlay %d: invalid end time %q", i+1, row.end.Text), h.window)
+				return
+			}
+			if overlay.End > 0 && overlay.End <= overlay.Start {
+				dialog.ShowError(fmt.Errorf("overlay %d: end time must be after the start time", i+1), h.window)
+				return
+			}
+			overlays = append(overlays, overlay)
+		}
+		h.state.SetOverlays(index, overlays)
+	}, h.window)
+	d.Resize(fyne.NewSize(500, 550))
+	d.Show()
+}
+
+// parseSecondsEntry parses a non-negative number of seconds typed into a
+// dialog; an empty entry is 0.
+func parseSecondsEntry(text string) (float64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseFloat(text, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+	return seconds, nil
+}
+
+// styleFields are the form fields for a TextStyle.
+type styleFields struct {
+	font  *widget.Entry
+	size  *widget.Entry
+	color *widget.Entry
+}
+
+func newStyleFields(style TextStyle) *styleFields {
+	f := &styleFields{
+		font:  widget.NewEntry(),
+		size:  widget.NewEntry(),
+		color: widget.NewEntry(),
+	}
+	f.font.SetText(style.Font)
+	f.font.SetPlaceHolder("Default, or a font name or file")
+	f.size.SetText(strconv.Itoa(style.FontSize))
+	f.color.SetText(style.Color)
+	f.color.SetPlaceHolder("white, #ffcc00, ...")
+	return f
+}
+
+func (f *styleFields) items() []*widget.FormItem {
+	return []*widget.FormItem{
+		widget.NewFormItem("Font", f.font),
+		widget.NewFormItem("Size (px at 1080p)", f.size),
+		widget.NewFormItem("Color", f.color),
+	}
+}
+
+func (f *styleFields) read() (TextStyle, error) {
+	style := TextStyle{
+		Font:  strings.TrimSpace(f.font.Text),
+		Color: strings.TrimSpace(f.color.Text),
+	}
+
+	size, err := strconv.Atoi(strings.TrimSpace(f.size.Text))
+	if err != nil || size <= 0 {
+		return style, fmt.Errorf("invalid font size %q", f.size.Text)
+	}
+	style.FontSize = size
+
+	if style.Color == "" {
+		style.Color = "white"
+	}
+	return style, nil
+}
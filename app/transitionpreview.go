@@ -0,0 +1,80 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+)
+
+const (
+	previewWidth  = 160
+	previewHeight = 90
+	previewFrames = 15
+)
+
+// RenderTransitionPreview renders a small animation of a transition from
+// the end of clip a into the start of clip b, for showing in the export
+// dialog. It returns the frames in order, or none for TransitionNone.
+func RenderTransitionPreview(ctx context.Context, a, b *Video, transition TransitionType, duration float64) ([]image.Image, error) {
+	if transition == TransitionNone {
+		return nil, nil
+	}
+
+	duration = min(duration, a.TrimmedDuration().Seconds(), b.TrimmedDuration().Seconds())
+	if duration <= 0 {
+		return nil, fmt.Errorf("clips are too short to preview")
+	}
+
+	xfade := transition.info().xfade
+	if xfade == "" {
+		// The plain fade doesn't overlap the clips, but looks the same as
+		// fading through black
+		xfade = "fadeblack"
+	}
+
+	scale := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%.3f,format=yuv420p",
+		previewWidth, previewHeight, previewWidth, previewHeight, previewFrames/duration)
+	filter := fmt.Sprintf("[0:v]%s[a];[1:v]%s[b];[a][b]xfade=transition=%s:duration=%.3f:offset=0",
+		scale, scale, xfade, duration)
+
+	start := a.EffectiveOutPoint().Seconds() - duration
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-autorotate", "-ss", fmt.Sprintf("%.3f", start), "-t", fmt.Sprintf("%.3f", duration), "-i", a.Path,
+		"-autorotate", "-ss", fmt.Sprintf("%.3f", b.InPoint.Seconds()), "-t", fmt.Sprintf("%.3f", duration), "-i", b.Path,
+		"-filter_complex", filter,
+		"-frames:v", fmt.Sprint(previewFrames),
+		"-f", "image2pipe",
+		"-vcodec", "png",
+		"-")
+
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("ffmpeg error: %w\n%s", err, stderr.String())
+	}
+
+	// The PNGs are written back to back; each Decode stops at its end
+	var frames []image.Image
+	r := bytes.NewReader(out.Bytes())
+	for r.Len() > 0 {
+		frame, err := png.Decode(r)
+		if err != nil {
+			break
+		}
+		frames = append(frames, frame)
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no preview frames")
+	}
+	return frames, nil
+}
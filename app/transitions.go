@@ -0,0 +1,232 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TransitionType int
+
+const (
+	TransitionNone      TransitionType = iota
+	TransitionFade                     // fade out to black, then in
+	TransitionCrossfade                // xfade "fade"
+	TransitionFadeBlack                // xfade through black
+	TransitionFadeWhite                // xfade through white
+)
+
+type transitionInfo struct {
+	id    string // stored in project files and accepted on the command line
+	name  string // shown in the export dialog
+	xfade string // ffmpeg xfade transition, empty if the clips don't overlap
+}
+
+// transitions is the catalog of transitions, indexed by TransitionType.
+// Entries are only ever appended so the constants above stay valid.
+var transitions = []transitionInfo{
+	{"none", "None", ""},
+	{"fade", "Fade", ""},
+	{"crossfade", "Crossfade", "fade"},
+	{"fadeblack", "Fade Through Black", "fadeblack"},
+	{"fadewhite", "Fade Through White", "fadewhite"},
+	{"fadegrays", "Fade Through Grays", "fadegrays"},
+	{"fadefast", "Fast Fade", "fadefast"},
+	{"fadeslow", "Slow Fade", "fadeslow"},
+	{"dissolve", "Dissolve", "dissolve"},
+	{"distance", "Distance", "distance"},
+	{"pixelize", "Pixelize", "pixelize"},
+	{"hblur", "Blur", "hblur"},
+	{"radial", "Radial", "radial"},
+	{"zoomin", "Zoom In", "zoomin"},
+	{"wipeleft", "Wipe Left", "wipeleft"},
+	{"wiperight", "Wipe Right", "wiperight"},
+	{"wipeup", "Wipe Up", "wipeup"},
+	{"wipedown", "Wipe Down", "wipedown"},
+	{"wipetl", "Wipe Top Left", "wipetl"},
+	{"wipetr", "Wipe Top Right", "wipetr"},
+	{"wipebl", "Wipe Bottom Left", "wipebl"},
+	{"wipebr", "Wipe Bottom Right", "wipebr"},
+	{"slideleft", "Slide Left", "slideleft"},
+	{"slideright", "Slide Right", "slideright"},
+	{"slideup", "Slide Up", "slideup"},
+	{"slidedown", "Slide Down", "slidedown"},
+	{"smoothleft", "Smooth Left", "smoothleft"},
+	{"smoothright", "Smooth Right", "smoothright"},
+	{"smoothup", "Smooth Up", "smoothup"},
+	{"smoothdown", "Smooth Down", "smoothdown"},
+	{"coverleft", "Cover Left", "coverleft"},
+	{"coverright", "Cover Right", "coverright"},
+	{"coverup", "Cover Up", "coverup"},
+	{"coverdown", "Cover Down", "coverdown"},
+	{"revealleft", "Reveal Left", "revealleft"},
+	{"revealright", "Reveal Right", "revealright"},
+	{"revealup", "Reveal Up", "revealup"},
+	{"revealdown", "Reveal Down", "revealdown"},
+	{"circleopen", "Circle Open", "circleopen"},
+	{"circleclose", "Circle Close", "circleclose"},
+	{"circlecrop", "Circle Crop", "circlecrop"},
+	{"rectcrop", "Rectangle Crop", "rectcrop"},
+	{"vertopen", "Vertical Open", "vertopen"},
+	{"vertclose", "Vertical Close", "vertclose"},
+	{"horzopen", "Horizontal Open", "horzopen"},
+	{"horzclose", "Horizontal Close", "horzclose"},
+	{"diagtl", "Diagonal Top Left", "diagtl"},
+	{"diagtr", "Diagonal Top Right", "diagtr"},
+	{"diagbl", "Diagonal Bottom Left", "diagbl"},
+	{"diagbr", "Diagonal Bottom Right", "diagbr"},
+	{"hlslice", "Slice Left", "hlslice"},
+	{"hrslice", "Slice Right", "hrslice"},
+	{"vuslice", "Slice Up", "vuslice"},
+	{"vdslice", "Slice Down", "vdslice"},
+	{"hlwind", "Wind Left", "hlwind"},
+	{"hrwind", "Wind Right", "hrwind"},
+	{"vuwind", "Wind Up", "vuwind"},
+	{"vdwind", "Wind Down", "vdwind"},
+	{"squeezeh", "Squeeze Horizontal", "squeezeh"},
+	{"squeezev", "Squeeze Vertical", "squeezev"},
+}
+
+// Transitions returns every transition in the order shown to the user.
+func Transitions() []TransitionType {
+	all := make([]TransitionType, len(transitions))
+	for i := range transitions {
+		all[i] = TransitionType(i)
+	}
+	return all
+}
+
+// TransitionNames returns the display names of all transitions.
+func TransitionNames() []string {
+	names := make([]string, len(transitions))
+	for i, info := range transitions {
+		names[i] = info.name
+	}
+	return names
+}
+
+func (t TransitionType) info() transitionInfo {
+	if t < 0 || int(t) >= len(transitions) {
+		return transitions[TransitionNone]
+	}
+	return transitions[t]
+}
+
+func (t TransitionType) String() string {
+	return t.info().name
+}
+
+// ID returns the short name stored in project files, e.g. "wipeleft".
+func (t TransitionType) ID() string {
+	return t.info().id
+}
+
+// Overlaps reports whether adjacent clips play at the same time during the
+// transition, which shortens the output by the transition duration.
+func (t TransitionType) Overlaps() bool {
+	return t.info().xfade != ""
+}
+
+// ParseTransitionType looks up a transition by its display name or ID,
+// ignoring case.
+func ParseTransitionType(name string) (TransitionType, error) {
+	for i, info := range transitions {
+		if strings.EqualFold(name, info.name) || strings.EqualFold(name, info.id) {
+			return TransitionType(i), nil
+		}
+	}
+	return TransitionNone, fmt.Errorf("unknown transition %q", name)
+}
+
+// MarshalText stores transitions by ID in project files.
+func (t TransitionType) MarshalText() ([]byte, error) {
+	return []byte(t.ID()), nil
+}
+
+func (t *TransitionType) UnmarshalText(text []byte) error {
+	parsed, err := ParseTransitionType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Transition is the transition at the boundary after a clip.
+type Transition struct {
+	Type     TransitionType `json:"type"`
+	Duration float64        `json:"duration,omitempty"` // in seconds, zero for the export default
+}
+
+func (t Transition) String() string {
+	if t.Type == TransitionNone {
+		return "Cut"
+	}
+	return fmt.Sprintf("%s %gs", t.Type, t.Duration)
+}
+
+// Overlap returns how much the clips on either side of the boundary
+// overlap in the output.
+func (t Transition) Overlap() time.Duration {
+	if !t.Type.Overlaps() {
+		return 0
+	}
+	return secondsToDuration(t.Duration)
+}
+
+// BoundaryTransition returns the transition after video: its own, or the
+// export default with the default duration filled in.
+func BoundaryTransition(video *Video, options ExportOptions) Transition {
+	defaultDuration := options.TransitionDuration
+	if defaultDuration <= 0 {
+		defaultDuration = 1.0
+	}
+
+	if video.TransitionOut == nil {
+		return Transition{Type: options.Transition, Duration: defaultDuration}
+	}
+
+	t := *video.TransitionOut
+	if t.Duration <= 0 {
+		t.Duration = defaultDuration
+	}
+	return t
+}
+
+// boundaryTransitions resolves the transition between each pair of
+// adjacent clips. A transition is shortened to fit the clips on either
+// side, counting only what is left of the first clip after the previous
+// transition overlapped its start, and becomes a cut if nothing is left.
+func boundaryTransitions(videos []*Video, options ExportOptions) []Transition {
+	if len(videos) < 2 {
+		return nil
+	}
+
+	boundaries := make([]Transition, len(videos)-1)
+	for i := range boundaries {
+		t := BoundaryTransition(videos[i], options)
+		current, next := videos[i].TrimmedDuration(), videos[i+1].TrimmedDuration()
+		if current > 0 && next > 0 {
+			if i > 0 {
+				current -= boundaries[i-1].Overlap()
+			}
+			t.Duration = min(t.Duration, current.Seconds(), next.Seconds())
+			if t.Duration <= 0 {
+				// Nothing is left to transition over
+				t.Type, t.Duration = TransitionNone, 0
+			}
+		}
+		boundaries[i] = t
+	}
+	return boundaries
+}
+
+// allCuts reports whether the clips are joined without any transition.
+func allCuts(boundaries []Transition) bool {
+	for _, t := range boundaries {
+		if t.Type != TransitionNone {
+			return false
+		}
+	}
+	return true
+}
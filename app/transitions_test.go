@@ -0,0 +1,68 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoundaryTransitions(t *testing.T) {
+	clip := func(length time.Duration) *Video {
+		return &Video{Path: "clip.mp4", Duration: length}
+	}
+	crossfades := ExportOptions{Transition: TransitionCrossfade, TransitionDuration: 1}
+
+	tests := []struct {
+		name    string
+		videos  []*Video
+		options ExportOptions
+		want    []Transition
+	}{
+		{
+			name:    "transitions fit",
+			videos:  []*Video{clip(4 * time.Second), clip(4 * time.Second), clip(4 * time.Second)},
+			options: crossfades,
+			want:    []Transition{{TransitionCrossfade, 1}, {TransitionCrossfade, 1}},
+		},
+		{
+			name:    "shortened to the next clip",
+			videos:  []*Video{clip(4 * time.Second), clip(500 * time.Millisecond)},
+			options: crossfades,
+			want:    []Transition{{TransitionCrossfade, 0.5}},
+		},
+		{
+			name:    "shortened to what the previous overlap leaves",
+			videos:  []*Video{clip(4 * time.Second), clip(1500 * time.Millisecond), clip(4 * time.Second)},
+			options: crossfades,
+			want:    []Transition{{TransitionCrossfade, 1}, {TransitionCrossfade, 0.5}},
+		},
+		{
+			name:    "clip consumed by the previous overlap",
+			videos:  []*Video{clip(4 * time.Second), clip(time.Second), clip(4 * time.Second)},
+			options: crossfades,
+			want:    []Transition{{TransitionCrossfade, 1}, {TransitionNone, 0}},
+		},
+		{
+			name: "clip transition overrides the default",
+			videos: []*Video{
+				{Path: "clip.mp4", Duration: 4 * time.Second, TransitionOut: &Transition{Type: TransitionFadeBlack, Duration: 2}},
+				clip(4 * time.Second),
+			},
+			options: crossfades,
+			want:    []Transition{{TransitionFadeBlack, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := boundaryTransitions(tt.videos, tt.options)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d boundaries, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type || !closeSeconds(got[i].Duration, tt.want[i].Duration) {
+					t.Errorf("boundary %d is %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -1,109 +1,156 @@
 package app
 
 import (
-	"bytes"
 	"fmt"
 	"image"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 )
 
+type VideoStatus int
+
+const (
+	VideoReady VideoStatus = iota
+	VideoProbing
+	VideoFailed
+	VideoOffline // the source file is missing, see FindRelinks
+)
+
 type Video struct {
 	Path      string
 	Name      string
 	Size      int64
+	ModTime   time.Time
+	Hash      string // see QuickHash
 	Duration  time.Duration
 	Width     int
 	Height    int
+	HasAudio  bool
+	Media     MediaInfo
 	Thumbnail image.Image
+
+	// Status tracks placeholders whose metadata is still being loaded in
+	// the background; LoadErr is set when that failed.
+	Status  VideoStatus
+	LoadErr error
+
+	// SourceChanged is set when the file no longer matches the fingerprint
+	// saved in the project, which may leave the trim points off.
+	SourceChanged bool
+	savedSource   Fingerprint
+
+	// InPoint and OutPoint trim the clip to a sub-range of the source file.
+	// A zero OutPoint means the clip plays to the end of the file.
+	InPoint  time.Duration
+	OutPoint time.Duration
+
+	// TransitionOut overrides the export's transition at the boundary
+	// after this clip. Nil uses the default from ExportOptions.
+	TransitionOut *Transition
+
+	// ChapterTitle overrides the clip's name as its chapter in the export.
+	ChapterTitle string
+
+	// Title is set for generated title cards, which have no Path.
+	Title    *TitleCard
+	Overlays []TextOverlay
 }
 
 func NewVideo(path string) (*Video, error) {
-	info, err := os.Stat(path)
+	video, err := ProbeVideo(path)
 	if err != nil {
 		return nil, err
 	}
 
-	video := &Video{
-		Path: path,
-		Name: filepath.Base(path),
-		Size: info.Size(),
-	}
-
-	if thumb, err := ExtractThumbnail(path); err == nil {
+	if thumb, err := LoadThumbnail(path, video.Size, video.ModTime); err == nil {
 		video.Thumbnail = thumb
 	}
 
-	if duration, err := ExtractDuration(path); err == nil {
-		video.Duration = duration
-	}
-
-	if width, height, err := ExtractResolution(path); err == nil {
-		video.Width = width
-		video.Height = height
-	}
-
 	return video, nil
 }
 
-func ExtractDuration(videoPath string) (time.Duration, error) {
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		videoPath)
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	if err := cmd.Run(); err != nil {
-		return 0, err
+// NewPlaceholder returns a video that can be shown in the list right away,
+// with only the name and size filled in. Its metadata is loaded later.
+func NewPlaceholder(path string) *Video {
+	video := &Video{
+		Path:   path,
+		Name:   filepath.Base(path),
+		Status: VideoProbing,
 	}
 
-	durationStr := strings.TrimSpace(out.String())
-	seconds, err := strconv.ParseFloat(durationStr, 64)
-	if err != nil {
-		return 0, err
+	if info, err := os.Stat(path); os.IsNotExist(err) {
+		video.Status = VideoOffline
+		video.LoadErr = fmt.Errorf("file not found")
+	} else if err != nil {
+		video.Status = VideoFailed
+		video.LoadErr = err
+	} else {
+		video.Size = info.Size()
+		video.ModTime = info.ModTime()
 	}
 
-	return time.Duration(seconds * float64(time.Second)), nil
+	return video
 }
 
-func ExtractResolution(videoPath string) (int, int, error) {
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-select_streams", "v:0",
-		"-show_entries", "stream=width,height",
-		"-of", "csv=s=x:p=0",
-		videoPath)
+// copySettings copies the per-clip settings, such as trim points, from
+// another video of the same source, e.g. when relinking.
+func (v *Video) copySettings(from *Video) {
+	v.InPoint = from.InPoint
+	v.OutPoint = from.OutPoint
+	v.TransitionOut = from.TransitionOut
+	v.ChapterTitle = from.ChapterTitle
+	v.Overlays = from.Overlays
+	v.savedSource = from.savedSource
+}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
+func (v *Video) Fingerprint() Fingerprint {
+	return Fingerprint{Size: v.Size, ModTime: v.ModTime, Hash: v.Hash}
+}
 
-	if err := cmd.Run(); err != nil {
-		return 0, 0, err
-	}
+// checkSource compares the file against the fingerprint saved in the
+// project, if there is one.
+func (v *Video) checkSource() {
+	v.SourceChanged = !v.savedSource.IsZero() && !v.savedSource.Matches(v.Fingerprint())
+}
 
-	parts := strings.Split(strings.TrimSpace(out.String()), "x")
-	if len(parts) != 2 {
-		return 0, 0, fmt.Errorf("invalid resolution format")
+// ProbeVideo reads the file metadata without extracting a thumbnail, for
+// callers that never display the video. An unreadable file is an error;
+// a file ffprobe can't read is returned with Status VideoFailed.
+func ProbeVideo(path string) (*Video, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
 	}
 
-	width, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, err
+	video := &Video{
+		Path:    path,
+		Name:    filepath.Base(path),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
 	}
+	video.Hash, _ = QuickHash(path)
 
-	height, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, 0, err
+	cache := DefaultMediaCache()
+	if media, ok := cache.LoadMedia(path, video.Size, video.ModTime); ok {
+		video.setMedia(media)
+	} else if media, err := ProbeMedia(path); err == nil {
+		video.setMedia(media)
+		cache.StoreMedia(path, video.Size, video.ModTime, media)
+	} else {
+		// Keep the video so it can be shown with an error
+		video.Status = VideoFailed
+		video.LoadErr = fmt.Errorf("ffprobe failed: %w", err)
 	}
 
-	return width, height, nil
+	return video, nil
+}
+
+func (v *Video) setMedia(media MediaInfo) {
+	v.Media = media
+	v.Duration = media.Duration
+	v.Width, v.Height = media.DisplaySize()
+	v.HasAudio = media.HasAudio()
 }
 
 func (v *Video) ResolutionString() string {
@@ -117,23 +164,68 @@ func (v *Video) DurationString() string {
 	if v.Duration == 0 {
 		return ""
 	}
+	return FormatTimecode(v.Duration)
+}
 
-	total := int(v.Duration.Seconds())
-	hours := total / 3600
-	minutes := (total % 3600) / 60
-	seconds := total % 60
+func (v *Video) IsTrimmed() bool {
+	return v.InPoint > 0 || (v.OutPoint > 0 && v.OutPoint < v.Duration)
+}
 
-	if hours > 0 {
-		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+// EffectiveOutPoint returns the point where the clip ends in the source file.
+func (v *Video) EffectiveOutPoint() time.Duration {
+	if v.OutPoint > 0 && (v.Duration == 0 || v.OutPoint < v.Duration) {
+		return v.OutPoint
 	}
-	return fmt.Sprintf("%d:%02d", minutes, seconds)
+	return v.Duration
+}
+
+// TrimmedDuration returns the length of the clip after applying its trim points.
+func (v *Video) TrimmedDuration() time.Duration {
+	d := v.EffectiveOutPoint() - v.InPoint
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func (v *Video) TrimmedDurationString() string {
+	if v.TrimmedDuration() == 0 {
+		return ""
+	}
+	return FormatTimecode(v.TrimmedDuration())
+}
+
+func (v *Video) SetTrim(in, out time.Duration) error {
+	if in < 0 || out < 0 {
+		return fmt.Errorf("trim points cannot be negative")
+	}
+	if out > 0 && out <= in {
+		return fmt.Errorf("out point must be after in point")
+	}
+	if v.Duration > 0 && in >= v.Duration {
+		return fmt.Errorf("in point is past the end of the clip")
+	}
+	if v.Duration > 0 && out >= v.Duration {
+		out = 0
+	}
+
+	v.InPoint = in
+	v.OutPoint = out
+	return nil
 }
 
 func (v *Video) FolderPath() string {
+	if v.IsTitle() {
+		return ""
+	}
 	return filepath.Dir(v.Path)
 }
 
 func (v *Video) SizeString() string {
+	return FormatBytes(v.Size)
+}
+
+func FormatBytes(size int64) string {
 	const (
 		KB = 1024
 		MB = KB * 1024
@@ -141,14 +233,14 @@ func (v *Video) SizeString() string {
 	)
 
 	switch {
-	case v.Size >= GB:
-		return formatSize(float64(v.Size)/GB, "GB")
-	case v.Size >= MB:
-		return formatSize(float64(v.Size)/MB, "MB")
-	case v.Size >= KB:
-		return formatSize(float64(v.Size)/KB, "KB")
+	case size >= GB:
+		return formatSize(float64(size)/GB, "GB")
+	case size >= MB:
+		return formatSize(float64(size)/MB, "MB")
+	case size >= KB:
+		return formatSize(float64(size)/KB, "KB")
 	default:
-		return formatSize(float64(v.Size), "B")
+		return formatSize(float64(size), "B")
 	}
 }
 
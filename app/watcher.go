@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long a file has to stay unchanged before it is
+// reloaded, so a file being copied or rendered isn't probed half-written.
+const watchDebounce = time.Second
+
+// SourceWatcher watches the source files of the clips in the list and
+// reloads a clip's metadata and thumbnail when its file changes on disk.
+// It watches the containing folders rather than the files, so files that
+// are replaced by renaming over them are picked up too.
+type SourceWatcher struct {
+	state   *State
+	watcher *fsnotify.Watcher
+
+	mu      sync.Mutex
+	dirs    map[string]bool
+	pending map[string]*time.Timer
+}
+
+func NewSourceWatcher(state *State) (*SourceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &SourceWatcher{
+		state:   state,
+		watcher: watcher,
+		dirs:    make(map[string]bool),
+		pending: make(map[string]*time.Timer),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Sync watches the folders of the clips currently in the list and stops
+// watching folders that no longer hold any. Call it after the list
+// changes. A nil watcher does nothing.
+func (w *SourceWatcher) Sync() {
+	if w == nil {
+		return
+	}
+
+	wanted := make(map[string]bool)
+	for _, video := range w.state.GetVideos() {
+		if !video.IsTitle() {
+			wanted[filepath.Dir(video.Path)] = true
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for dir := range w.dirs {
+		if !wanted[dir] {
+			w.watcher.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	for dir := range wanted {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			log.Printf("Cannot watch %s: %v", dir, err)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+}
+
+func (w *SourceWatcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.watcher.Close()
+}
+
+func (w *SourceWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			w.schedule(event.Name)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("File watcher error: %v", err)
+		}
+	}
+}
+
+// schedule reloads path once it has been quiet for watchDebounce.
+func (w *SourceWatcher) schedule(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if timer, ok := w.pending[path]; ok {
+		timer.Reset(watchDebounce)
+		return
+	}
+	w.pending[path] = time.AfterFunc(watchDebounce, func() {
+		w.mu.Lock()
+		delete(w.pending, path)
+		w.mu.Unlock()
+
+		w.state.ReloadSource(path)
+	})
+}
+
+// ReloadSource reloads the metadata and thumbnail of every clip that uses
+// path, e.g. after the file was re-encoded. Clip settings are kept; a clip
+// whose file was deleted goes offline. It is not an undoable change.
+func (s *State) ReloadSource(path string) {
+	info, statErr := os.Stat(path)
+
+	s.mu.Lock()
+	var reload []*Video
+	var changed []int
+	for i, video := range s.videos {
+		if video.Path != path || video.Status == VideoProbing {
+			continue
+		}
+		// Events also arrive for files that were only read or touched
+		if statErr == nil && video.Status == VideoReady && video.Size == info.Size() && video.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		switch {
+		case os.IsNotExist(statErr):
+			if video.Status == VideoOffline {
+				continue
+			}
+			video.Status = VideoOffline
+			video.LoadErr = statErr
+		case statErr != nil:
+			video.Status = VideoFailed
+			video.LoadErr = statErr
+		default:
+			video.Status = VideoProbing
+			video.LoadErr = nil
+			video.Thumbnail = nil
+			reload = append(reload, video)
+		}
+		changed = append(changed, i)
+	}
+	s.mu.Unlock()
+
+	for _, i := range changed {
+		s.notifyVideoChange(i)
+	}
+	if len(reload) > 0 {
+		log.Printf("Reloading %s after it changed on disk", path)
+		s.loadInBackground(context.Background(), reload, 1, nil)
+	}
+}
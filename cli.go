@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	appPkg "video-arranger/app"
+)
+
+const renderUsage = `Usage: video-arranger render <project.json> -o <output> [options]
+
+Renders a saved project without opening a window. The transition, canvas,
+loudness and subtitle options override the export settings saved in the project.
+
+Options:
+`
+
+// runRender implements the headless "render" subcommand and returns the
+// process exit code.
+func runRender(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, renderUsage)
+		fs.PrintDefaults()
+	}
+
+	output := fs.String("o", "", "output video file")
+	transition := fs.String("transition", "none", "transition between clips: none, fade, crossfade or an ffmpeg xfade name such as wipeleft or fadeblack")
+	duration := fs.Float64("duration", 1.0, "transition duration in seconds")
+	canvas := fs.String("canvas", "auto", "canvas size (e.g. 1920x1080) used when clips have to be re-encoded to match")
+	fps := fs.Float64("fps", 0, "frame rate used when clips have to be re-encoded to match (0 = first clip's)")
+	loudness := fs.String("loudness", "off", "EBU R128 loudness normalization: off, program (the whole export) or clip (each clip)")
+	lufs := fs.Float64("lufs", -23, "integrated loudness target in LUFS")
+	truePeak := fs.Float64("true-peak", -1, "maximum true peak in dBTP")
+	lra := fs.Float64("lra", 7, "loudness range target in LU")
+	subtitles := fs.String("subtitles", "off", "clip subtitles: off, track (a subtitle track), sidecar (an .srt file next to the output) or burn (drawn into the picture)")
+	jobs := fs.Int("jobs", 0, "number of clips probed in parallel (0 = one per CPU)")
+
+	// Accept flags both before and after the project path
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) != 1 || *output == "" {
+		fs.Usage()
+		return 2
+	}
+
+	project, err := appPkg.LoadProject(positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load project: %v\n", err)
+		return 1
+	}
+	clips := project.Clips
+
+	// The project's export options apply unless overridden on the command line
+	options := project.Export
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if set["transition"] {
+		if options.Transition, err = appPkg.ParseTransitionType(*transition); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	if set["duration"] {
+		options.TransitionDuration = *duration
+	}
+	if set["canvas"] {
+		frameRate := options.Canvas.FrameRate
+		if options.Canvas, err = appPkg.ParseCanvas(*canvas); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		options.Canvas.FrameRate = frameRate
+	}
+	if set["fps"] {
+		options.Canvas.FrameRate = *fps
+	}
+	if options.TransitionDuration <= 0 {
+		options.TransitionDuration = 1.0
+	}
+	if set["loudness"] {
+		if options.Loudness.Scope, err = appPkg.ParseLoudnessScope(*loudness); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	if options.Loudness.Enabled() && options.Loudness == (appPkg.Loudness{Scope: options.Loudness.Scope}) {
+		// Turned on without targets, e.g. on the command line
+		scope := options.Loudness.Scope
+		options.Loudness = appPkg.DefaultLoudness()
+		options.Loudness.Scope = scope
+	}
+	if set["lufs"] {
+		options.Loudness.Target = *lufs
+	}
+	if set["true-peak"] {
+		options.Loudness.TruePeak = *truePeak
+	}
+	if set["lra"] {
+		options.Loudness.Range = *lra
+	}
+	if err := options.Loudness.Validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if set["subtitles"] {
+		if options.Subtitles, err = appPkg.ParseSubtitleMode(*subtitles); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+
+	// Ctrl+C stops probing or ffmpeg and removes the partial output
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Title cards are generated, so only the video files are probed
+	videos := make([]*appPkg.Video, len(clips))
+	var paths []string
+	var probed []int
+	for i, clip := range clips {
+		if clip.Title != nil {
+			videos[i], _ = clip.NewVideo()
+			continue
+		}
+		paths = append(paths, clip.Path)
+		probed = append(probed, i)
+	}
+
+	results := appPkg.ImportVideos(ctx, paths, *jobs, appPkg.ProbeVideo, nil)
+	if ctx.Err() != nil {
+		fmt.Fprintln(stderr, "Cancelled")
+		return 1
+	}
+	if summary := appPkg.ImportSummary(results); summary != "" {
+		fmt.Fprintln(stderr, summary)
+		return 1
+	}
+
+	for j, result := range results {
+		i := probed[j]
+		if err := clips[i].Apply(result.Video); err != nil {
+			fmt.Fprintf(stderr, "Invalid trim points for %s: %v\n", result.Path, err)
+			return 1
+		}
+		if result.Video.SourceChanged {
+			fmt.Fprintf(stderr, "Warning: %s changed since the project was saved\n", result.Path)
+		}
+		videos[i] = result.Video
+	}
+
+	progress := make(chan appPkg.ExportProgress)
+	go appPkg.ExportVideos(ctx, videos, *output, options, progress)
+
+	exitCode := 0
+	for p := range progress {
+		if p.Error != nil {
+			fmt.Fprintf(stderr, "Export failed: %v\n", p.Error)
+			exitCode = 1
+			continue
+		}
+		if p.Cancelled {
+			exitCode = 1
+		}
+		fmt.Fprintln(stdout, p)
+		if p.Detail != "" {
+			fmt.Fprintln(stdout, p.Detail)
+		}
+	}
+
+	return exitCode
+}
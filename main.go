@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -24,6 +26,10 @@ func isVideoFile(path string) bool {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(runRender(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	a := app.NewWithID("com.videoarranger.app")
 	window := a.NewWindow("Video Arranger")
 
@@ -31,8 +37,34 @@ func main() {
 	handlers := appPkg.NewHandlers(state, window)
 	layout := ui.NewMainLayout(state, handlers)
 
+	// Reload clips whose files change on disk while the project is open
+	watcher, err := appPkg.NewSourceWatcher(state)
+	if err != nil {
+		log.Printf("Not watching source files: %v", err)
+	}
+	defer watcher.Close()
+
+	updateStatusBar := func() {
+		count := state.Count()
+		if count == 0 {
+			layout.StatusBar.SetText("No videos")
+			return
+		}
+
+		status := fmt.Sprintf("%d videos", count)
+		if totalDuration := state.TotalDurationString(); totalDuration != "" {
+			status += " | Total: " + totalDuration
+		}
+		if selectedCount := state.SelectionCount(); selectedCount > 1 {
+			status += fmt.Sprintf(" | %d selected", selectedCount)
+		}
+		layout.StatusBar.SetText(status)
+	}
+
 	state.SetOnChange(func() {
+		watcher.Sync()
 		layout.VideoList.Refresh()
+		layout.ImportBar.SetProgress(state.ImportProgress())
 
 		selected := state.GetSelected()
 		videos := state.GetVideos()
@@ -42,18 +74,21 @@ func main() {
 			layout.PreviewPane.SetVideo(nil)
 		}
 
-		// Update status bar
-		count := state.Count()
-		if count == 0 {
-			layout.StatusBar.SetText("No videos")
-		} else {
-			totalDuration := state.TotalDurationString()
-			if totalDuration != "" {
-				layout.StatusBar.SetText(fmt.Sprintf("%d videos | Total: %s", count, totalDuration))
-			} else {
-				layout.StatusBar.SetText(fmt.Sprintf("%d videos", count))
+		updateStatusBar()
+	})
+
+	// Rows update on their own as videos finish loading in the background
+	state.SetOnVideoChange(func(index int) {
+		fyne.Do(func() {
+			layout.VideoList.RefreshItem(index)
+			layout.ImportBar.SetProgress(state.ImportProgress())
+			updateStatusBar()
+
+			videos := state.GetVideos()
+			if selected := state.GetSelected(); selected == index && index < len(videos) {
+				layout.PreviewPane.SetVideo(videos[index])
 			}
-		}
+		})
 	})
 
 	// Keyboard shortcuts
@@ -85,6 +120,28 @@ func main() {
 		handlers.OnExport()
 	})
 
+	window.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyA,
+		Modifier: fyne.KeyModifierShortcutDefault,
+	}, func(_ fyne.Shortcut) {
+		state.SelectAll()
+	})
+
+	// Undo/redo use Cmd on macOS and Ctrl elsewhere
+	window.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyZ,
+		Modifier: fyne.KeyModifierShortcutDefault,
+	}, func(_ fyne.Shortcut) {
+		handlers.OnUndo()
+	})
+
+	window.Canvas().AddShortcut(&desktop.CustomShortcut{
+		KeyName:  fyne.KeyZ,
+		Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift,
+	}, func(_ fyne.Shortcut) {
+		handlers.OnRedo()
+	})
+
 	window.Canvas().AddShortcut(&desktop.CustomShortcut{
 		KeyName: fyne.KeyUp,
 	}, func(_ fyne.Shortcut) {
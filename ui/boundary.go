@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"video-arranger/app"
+)
+
+// boundaryMarker sits between two rows of the video list and shows the
+// transition between them. Clicking it edits the transition.
+type boundaryMarker struct {
+	widget.BaseWidget
+	list   *VideoList
+	index  int // of the clip before the boundary
+	button *widget.Button
+}
+
+func newBoundaryMarker(list *VideoList) *boundaryMarker {
+	m := &boundaryMarker{list: list}
+	m.button = widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		if m.list.OnEditTransition != nil {
+			m.list.OnEditTransition(m.index)
+		}
+	})
+	m.button.Importance = widget.LowImportance
+	m.ExtendBaseWidget(m)
+	return m
+}
+
+func (m *boundaryMarker) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewCenter(m.button))
+}
+
+func (m *boundaryMarker) update(index int, video *app.Video, defaults app.ExportOptions) {
+	m.index = index
+
+	text := app.BoundaryTransition(video, defaults).String()
+	if video.TransitionOut == nil {
+		text += " (default)"
+	}
+	m.button.SetText(text)
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ImportBar shows the progress of videos loading in the background without
+// blocking the rest of the window. It is hidden while nothing is loading.
+type ImportBar struct {
+	widget.BaseWidget
+	label       *widget.Label
+	progressBar *widget.ProgressBar
+	container   *fyne.Container
+}
+
+func NewImportBar(onCancel func()) *ImportBar {
+	b := &ImportBar{
+		label:       widget.NewLabel(""),
+		progressBar: widget.NewProgressBar(),
+	}
+
+	cancelBtn := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), onCancel)
+	b.container = container.NewBorder(nil, nil, b.label, cancelBtn, b.progressBar)
+
+	b.ExtendBaseWidget(b)
+	b.Hide()
+	return b
+}
+
+func (b *ImportBar) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(b.container)
+}
+
+// SetProgress updates the bar with the number of videos loaded so far, and
+// hides it once total is zero.
+func (b *ImportBar) SetProgress(done, total int) {
+	if total == 0 {
+		b.Hide()
+		return
+	}
+
+	b.label.SetText(fmt.Sprintf("Loaded %d of %d videos", done, total))
+	b.progressBar.SetValue(float64(done) / float64(total))
+	b.Show()
+}
@@ -13,34 +13,48 @@ type MainLayout struct {
 	VideoList   *VideoList
 	PreviewPane *PreviewPane
 	StatusBar   *widget.Label
+	ImportBar   *ImportBar
 }
 
 func NewMainLayout(state *app.State, handlers *app.Handlers) *MainLayout {
 	videoList := NewVideoList(state)
+	videoList.OnEditTransition = handlers.OnEditTransition
 
 	previewPane := NewPreviewPane(func(path string) {
 		app.PlayVideo(path)
-	})
+	}, handlers.OnTrim, handlers.OnEditText, handlers.OnChapterTitle)
 
 	toolbar := NewToolbar(ToolbarHandlers{
-		OnNew:       handlers.OnNew,
-		OnAdd:       handlers.OnAddVideos,
-		OnAddFolder: handlers.OnAddFolder,
-		OnRemove:    handlers.OnRemove,
-		OnMoveUp:    handlers.OnMoveUp,
-		OnMoveDown:  handlers.OnMoveDown,
-		OnClear:     handlers.OnClear,
-		OnExport:    handlers.OnExport,
-		OnSave:      handlers.OnSave,
-		OnLoad:      handlers.OnLoad,
+		OnNew:        handlers.OnNew,
+		OnAdd:        handlers.OnAddVideos,
+		OnAddFolder:  handlers.OnAddFolder,
+		OnAddTitle:   handlers.OnAddTitle,
+		OnMusic:      handlers.OnMusic,
+		OnRemove:     handlers.OnRemove,
+		OnMoveUp:     handlers.OnMoveUp,
+		OnMoveDown:   handlers.OnMoveDown,
+		OnUndo:       handlers.OnUndo,
+		OnRedo:       handlers.OnRedo,
+		OnClear:      handlers.OnClear,
+		OnExport:     handlers.OnExport,
+		OnSave:       handlers.OnSave,
+		OnLoad:       handlers.OnLoad,
+		OnRelink:     handlers.OnRelink,
+		OnImportEDL:  handlers.OnImportEDL,
+		OnExportEDL:  handlers.OnExportEDL,
+		OnExportXML:  handlers.OnExportFCPXML,
+		OnClearCache: handlers.OnClearCache,
+		OnSettings:   handlers.OnSettings,
 	})
 
-	header := widget.NewLabel("Video Files (drag to reorder)")
+	header := widget.NewLabel("Video Files (drag to reorder, Shift/Ctrl-click to select several)")
 	header.TextStyle = fyne.TextStyle{Bold: true}
 
 	statusBar := widget.NewLabel("No videos")
 	statusBar.Alignment = fyne.TextAlignCenter
 
+	importBar := NewImportBar(handlers.OnCancelImport)
+
 	listWithHeader := container.NewBorder(
 		header,
 		nil, nil, nil,
@@ -52,7 +66,7 @@ func NewMainLayout(state *app.State, handlers *app.Handlers) *MainLayout {
 
 	content := container.NewBorder(
 		toolbar,
-		statusBar,
+		container.NewVBox(importBar, statusBar),
 		nil, nil,
 		split,
 	)
@@ -62,5 +76,6 @@ func NewMainLayout(state *app.State, handlers *app.Handlers) *MainLayout {
 		VideoList:   videoList,
 		PreviewPane: previewPane,
 		StatusBar:   statusBar,
+		ImportBar:   importBar,
 	}
 }
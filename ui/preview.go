@@ -1,8 +1,11 @@
 package ui
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -22,13 +25,28 @@ type PreviewPane struct {
 	resolutionLabel *widget.Label
 	sizeLabel       *widget.Label
 	playBtn         *widget.Button
+	textBtn         *widget.Button
+	inEntry         *widget.Entry
+	outEntry        *widget.Entry
+	trimBtn         *widget.Button
+	resetTrimBtn    *widget.Button
+	chapterEntry    *widget.Entry
+	chapterBtn      *widget.Button
+	mediaForm       *widget.Form
+	mediaInfo       *widget.Accordion
 	currentPath     string
 	onPlay          func(path string)
+	onTrim          func(in, out time.Duration)
+	onEditText      func()
+	onChapter       func(title string)
 }
 
-func NewPreviewPane(onPlay func(path string)) *PreviewPane {
+func NewPreviewPane(onPlay func(path string), onTrim func(in, out time.Duration), onEditText func(), onChapter func(title string)) *PreviewPane {
 	p := &PreviewPane{
-		onPlay: onPlay,
+		onPlay:     onPlay,
+		onTrim:     onTrim,
+		onEditText: onEditText,
+		onChapter:  onChapter,
 	}
 
 	p.thumbnail = canvas.NewImageFromImage(nil)
@@ -59,6 +77,61 @@ func NewPreviewPane(onPlay func(path string)) *PreviewPane {
 	})
 	p.playBtn.Disable()
 
+	p.textBtn = widget.NewButtonWithIcon("Edit Text...", theme.DocumentCreateIcon(), func() {
+		if p.onEditText != nil {
+			p.onEditText()
+		}
+	})
+	p.textBtn.Disable()
+
+	p.inEntry = widget.NewEntry()
+	p.inEntry.SetPlaceHolder("0:00.0")
+	p.outEntry = widget.NewEntry()
+	p.outEntry.SetPlaceHolder("End")
+
+	p.trimBtn = widget.NewButtonWithIcon("Apply Trim", theme.ContentCutIcon(), func() {
+		in, err := app.ParseTimecode(p.inEntry.Text)
+		if err != nil {
+			p.inEntry.SetValidationError(err)
+			return
+		}
+		out, err := app.ParseTimecode(p.outEntry.Text)
+		if err != nil {
+			p.outEntry.SetValidationError(err)
+			return
+		}
+		if p.onTrim != nil {
+			p.onTrim(in, out)
+		}
+	})
+	p.resetTrimBtn = widget.NewButtonWithIcon("Reset", theme.ContentUndoIcon(), func() {
+		if p.onTrim != nil {
+			p.onTrim(0, 0)
+		}
+	})
+
+	// The chapter title is applied with Enter or the Apply button; clearing
+	// it uses the name
+	applyChapter := func() {
+		if p.onChapter != nil {
+			p.onChapter(p.chapterEntry.Text)
+		}
+	}
+	p.chapterEntry = widget.NewEntry()
+	p.chapterEntry.OnSubmitted = func(string) { applyChapter() }
+	p.chapterEntry.Disable()
+	p.chapterBtn = widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), applyChapter)
+	p.chapterBtn.Disable()
+
+	trimForm := widget.NewForm(
+		widget.NewFormItem("In", p.inEntry),
+		widget.NewFormItem("Out", p.outEntry),
+	)
+	p.setTrimEnabled(false)
+
+	p.mediaForm = widget.NewForm()
+	p.mediaInfo = widget.NewAccordion(widget.NewAccordionItem("Media Info", p.mediaForm))
+
 	previewHeader := widget.NewLabel("Preview")
 	previewHeader.TextStyle = fyne.TextStyle{Bold: true}
 
@@ -69,7 +142,12 @@ func NewPreviewPane(onPlay func(path string)) *PreviewPane {
 		p.durationLabel,
 		p.resolutionLabel,
 		p.sizeLabel,
-		p.playBtn,
+		container.NewGridWithColumns(2, p.playBtn, p.textBtn),
+		widget.NewSeparator(),
+		trimForm,
+		container.NewGridWithColumns(2, p.trimBtn, p.resetTrimBtn),
+		widget.NewForm(widget.NewFormItem("Chapter", container.NewBorder(nil, nil, nil, p.chapterBtn, p.chapterEntry))),
+		p.mediaInfo,
 	)
 
 	p.ExtendBaseWidget(p)
@@ -90,15 +168,66 @@ func (p *PreviewPane) SetVideo(video *app.Video) {
 		p.resolutionLabel.SetText("")
 		p.sizeLabel.SetText("")
 		p.playBtn.Disable()
+		p.textBtn.Disable()
+		p.chapterEntry.SetText("")
+		p.chapterEntry.SetPlaceHolder("")
+		p.chapterEntry.Disable()
+		p.chapterBtn.Disable()
+		p.inEntry.SetText("")
+		p.outEntry.SetText("")
+		p.setTrimEnabled(false)
+		p.setMediaInfo(nil)
 		return
 	}
 
 	p.currentPath = video.Path
 	p.nameLabel.SetText(video.Name)
-	p.durationLabel.SetText(video.DurationString())
+	if video.IsTrimmed() {
+		p.durationLabel.SetText(video.TrimmedDurationString() + " of " + video.DurationString())
+	} else {
+		p.durationLabel.SetText(video.DurationString())
+	}
 	p.resolutionLabel.SetText(video.ResolutionString())
 	p.sizeLabel.SetText(video.SizeString())
 	p.playBtn.Enable()
+	p.textBtn.Enable()
+	p.chapterEntry.SetText(video.ChapterTitle)
+	p.chapterEntry.SetPlaceHolder(video.Name)
+	p.chapterEntry.Enable()
+	p.chapterBtn.Enable()
+	if video.IsTitle() {
+		// Title cards have no file to play or trim
+		p.sizeLabel.SetText("Title card")
+		p.playBtn.Disable()
+		p.textBtn.SetText("Edit Title...")
+	} else {
+		p.textBtn.SetText("Edit Text...")
+	}
+
+	p.inEntry.SetText("")
+	if video.InPoint > 0 {
+		p.inEntry.SetText(app.FormatTimecodePrecise(video.InPoint))
+	}
+	p.outEntry.SetText("")
+	if video.OutPoint > 0 {
+		p.outEntry.SetText(app.FormatTimecodePrecise(video.OutPoint))
+	}
+	// Trimming needs the duration, which is unknown until the probe is done
+	p.setTrimEnabled(video.Status == app.VideoReady && !video.IsTitle())
+	p.setMediaInfo(&video.Media)
+
+	switch video.Status {
+	case app.VideoProbing:
+		p.durationLabel.SetText("Probing...")
+	case app.VideoReady:
+		if video.SourceChanged {
+			p.durationLabel.SetText(p.durationLabel.Text + " (file changed since saved, check trim points)")
+		}
+	case app.VideoOffline:
+		p.durationLabel.SetText("Offline: file not found")
+	case app.VideoFailed:
+		p.durationLabel.SetText("Error: " + errorText(video.LoadErr))
+	}
 
 	if video.Thumbnail != nil {
 		p.thumbnail.Image = video.Thumbnail
@@ -107,3 +236,67 @@ func (p *PreviewPane) SetVideo(video *app.Video) {
 	}
 	p.thumbnail.Refresh()
 }
+
+func (p *PreviewPane) setTrimEnabled(enabled bool) {
+	if enabled {
+		p.inEntry.Enable()
+		p.outEntry.Enable()
+		p.trimBtn.Enable()
+		p.resetTrimBtn.Enable()
+	} else {
+		p.inEntry.Disable()
+		p.outEntry.Disable()
+		p.trimBtn.Disable()
+		p.resetTrimBtn.Disable()
+	}
+}
+
+func (p *PreviewPane) setMediaInfo(info *app.MediaInfo) {
+	p.mediaForm.Items = nil
+	if info == nil || info.VideoCodec == "" {
+		p.mediaForm.Refresh()
+		return
+	}
+
+	add := func(name, value string) {
+		if value != "" {
+			p.mediaForm.Append(name, widget.NewLabel(value))
+		}
+	}
+
+	add("Container", info.Container)
+	video := info.VideoCodec
+	if info.Profile != "" {
+		video += " (" + info.Profile + ")"
+	}
+	add("Video", video)
+	add("Frame rate", info.FrameRateString())
+	add("Bitrate", info.BitRateString())
+	add("Pixel format", info.PixelFormat)
+	add("Color space", info.ColorSpace)
+	if info.Rotation != 0 {
+		add("Rotation", fmt.Sprintf("%d°", info.Rotation))
+	}
+
+	if len(info.Audio) == 0 {
+		add("Audio", "None")
+	}
+	for i, audio := range info.Audio {
+		name := "Audio"
+		if len(info.Audio) > 1 {
+			name = fmt.Sprintf("Audio %d", i+1)
+		}
+		add(name, fmt.Sprintf("%s, %d ch, %d Hz", audio.Codec, audio.Channels, audio.SampleRate))
+	}
+
+	for i, subtitle := range info.Subtitles {
+		name := "Subtitles"
+		if len(info.Subtitles) > 1 {
+			name = fmt.Sprintf("Subtitles %d", i+1)
+		}
+		add(name, subtitle.String())
+	}
+
+	add("Languages", strings.Join(info.Languages, ", "))
+	p.mediaForm.Refresh()
+}
@@ -8,45 +8,78 @@ import (
 )
 
 type ToolbarHandlers struct {
-	OnNew       func()
-	OnAdd       func()
-	OnAddFolder func()
-	OnRemove    func()
-	OnMoveUp    func()
-	OnMoveDown  func()
-	OnClear     func()
-	OnExport    func()
-	OnSave      func()
-	OnLoad      func()
+	OnNew        func()
+	OnAdd        func()
+	OnAddFolder  func()
+	OnAddTitle   func()
+	OnMusic      func()
+	OnRemove     func()
+	OnMoveUp     func()
+	OnMoveDown   func()
+	OnUndo       func()
+	OnRedo       func()
+	OnClear      func()
+	OnExport     func()
+	OnSave       func()
+	OnLoad       func()
+	OnRelink     func()
+	OnImportEDL  func()
+	OnExportEDL  func()
+	OnExportXML  func()
+	OnClearCache func()
+	OnSettings   func()
 }
 
 func NewToolbar(handlers ToolbarHandlers) fyne.CanvasObject {
 	newBtn := widget.NewButtonWithIcon("New", theme.DocumentCreateIcon(), handlers.OnNew)
 	addBtn := widget.NewButtonWithIcon("Add Videos", theme.ContentAddIcon(), handlers.OnAdd)
 	addFolderBtn := widget.NewButtonWithIcon("Add Folder", theme.FolderIcon(), handlers.OnAddFolder)
+	addTitleBtn := widget.NewButtonWithIcon("Add Title", theme.FileTextIcon(), handlers.OnAddTitle)
+	musicBtn := widget.NewButtonWithIcon("Music", theme.MediaMusicIcon(), handlers.OnMusic)
 	removeBtn := widget.NewButtonWithIcon("Remove", theme.ContentRemoveIcon(), handlers.OnRemove)
 	upBtn := widget.NewButtonWithIcon("Move Up", theme.MoveUpIcon(), handlers.OnMoveUp)
 	downBtn := widget.NewButtonWithIcon("Move Down", theme.MoveDownIcon(), handlers.OnMoveDown)
+	undoBtn := widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), handlers.OnUndo)
+	redoBtn := widget.NewButtonWithIcon("Redo", theme.ContentRedoIcon(), handlers.OnRedo)
 	clearBtn := widget.NewButtonWithIcon("Clear All", theme.DeleteIcon(), handlers.OnClear)
 	exportBtn := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), handlers.OnExport)
 	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), handlers.OnSave)
 	loadBtn := widget.NewButtonWithIcon("Load", theme.FolderOpenIcon(), handlers.OnLoad)
+	relinkBtn := widget.NewButtonWithIcon("Relink", theme.SearchIcon(), handlers.OnRelink)
+	importEDLBtn := widget.NewButtonWithIcon("Import EDL", theme.UploadIcon(), handlers.OnImportEDL)
+	exportEDLBtn := widget.NewButtonWithIcon("Export EDL", theme.DownloadIcon(), handlers.OnExportEDL)
+	exportXMLBtn := widget.NewButtonWithIcon("Export FCPXML", theme.DownloadIcon(), handlers.OnExportXML)
+	clearCacheBtn := widget.NewButtonWithIcon("Clear Cache", theme.StorageIcon(), handlers.OnClearCache)
+	settingsBtn := widget.NewButtonWithIcon("Settings", theme.SettingsIcon(), handlers.OnSettings)
 
 	return container.NewHBox(
 		newBtn,
 		widget.NewSeparator(),
 		addBtn,
 		addFolderBtn,
+		addTitleBtn,
+		musicBtn,
 		removeBtn,
 		widget.NewSeparator(),
 		upBtn,
 		downBtn,
 		widget.NewSeparator(),
+		undoBtn,
+		redoBtn,
+		widget.NewSeparator(),
 		clearBtn,
 		widget.NewSeparator(),
 		saveBtn,
 		loadBtn,
+		relinkBtn,
+		widget.NewSeparator(),
+		importEDLBtn,
+		exportEDLBtn,
+		exportXMLBtn,
 		widget.NewSeparator(),
 		exportBtn,
+		widget.NewSeparator(),
+		clearCacheBtn,
+		settingsBtn,
 	)
 }
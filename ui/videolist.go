@@ -8,6 +8,7 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	"video-arranger/app"
@@ -43,9 +44,14 @@ type VideoList struct {
 	state       *app.State
 	container   *fyne.Container
 	items       []*videoItem
+	markers     []*boundaryMarker
 	dragIndex   int
 	dropIndex   int
 	isDragging  bool
+
+	// OnEditTransition is called with the index of the clip before a
+	// boundary when its marker is clicked.
+	OnEditTransition func(index int)
 }
 
 type videoItem struct {
@@ -54,10 +60,12 @@ type videoItem struct {
 	index       int
 	background  *canvas.Rectangle
 	img         *canvas.Image
+	badge       *widget.Icon // loading or error state, hidden when ready
 	label       *widget.Label
 	folderLabel *widget.Label
 	moveButtons *fyne.Container
 	container   *fyne.Container
+	modifier    fyne.KeyModifier // keys held on the last mouse down
 }
 
 func newVideoItem(list *VideoList) *videoItem {
@@ -65,11 +73,13 @@ func newVideoItem(list *VideoList) *videoItem {
 		list:        list,
 		background:  canvas.NewRectangle(color.Transparent),
 		img:         canvas.NewImageFromImage(nil),
+		badge:       widget.NewIcon(nil),
 		label:       widget.NewLabel(""),
 		folderLabel: widget.NewLabel(""),
 	}
 	item.img.SetMinSize(fyne.NewSize(120, 68))
 	item.img.FillMode = canvas.ImageFillContain
+	item.badge.Hide()
 
 	btnTop := widget.NewButton("Top", func() {
 		list.state.MoveToTop()
@@ -91,6 +101,7 @@ func newVideoItem(list *VideoList) *videoItem {
 	item.container = container.NewHBox(
 		item.img,
 		widget.NewSeparator(),
+		item.badge,
 		item.label,
 		widget.NewSeparator(),
 		item.folderLabel,
@@ -109,18 +120,34 @@ func (v *videoItem) Cursor() desktop.Cursor {
 	return desktop.PointerCursor
 }
 
+func (v *videoItem) MouseDown(e *desktop.MouseEvent) {
+	v.modifier = e.Modifier
+}
+
+func (v *videoItem) MouseUp(*desktop.MouseEvent) {}
+
 func (v *videoItem) Tapped(*fyne.PointEvent) {
-	v.list.state.SetSelected(v.index)
+	switch {
+	case v.modifier&fyne.KeyModifierShift != 0:
+		v.list.state.SelectRange(v.list.state.GetSelected(), v.index)
+	case v.modifier&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0:
+		v.list.state.ToggleSelect(v.index)
+	default:
+		v.list.state.SetSelected(v.index)
+	}
 }
 
 func (v *videoItem) Dragged(e *fyne.DragEvent) {
 	if !v.list.isDragging {
 		v.list.isDragging = true
 		v.list.dragIndex = v.index
-		v.list.state.SetSelected(v.index)
+		// Dragging a selected clip moves the whole selection with it
+		if !v.list.state.IsSelected(v.index) {
+			v.list.state.SetSelected(v.index)
+		}
 	}
 
-	itemHeight := float32(80)
+	itemHeight := v.list.rowHeight(v)
 	totalDrag := e.Position.Y - itemHeight/2
 	newIndex := v.list.dragIndex + int(totalDrag/itemHeight)
 
@@ -137,7 +164,12 @@ func (v *videoItem) Dragged(e *fyne.DragEvent) {
 
 func (v *videoItem) DragEnd() {
 	if v.list.isDragging && v.list.dragIndex != v.list.dropIndex {
-		v.list.state.Move(v.list.dragIndex, v.list.dropIndex)
+		// Dropping below the start position inserts after the target clip
+		to := v.list.dropIndex
+		if to > v.list.dragIndex {
+			to++
+		}
+		v.list.state.MoveSelection(to)
 	}
 	v.list.isDragging = false
 	v.list.clearHighlight()
@@ -145,14 +177,48 @@ func (v *videoItem) DragEnd() {
 
 func (v *videoItem) update(index int, video *app.Video) {
 	v.index = index
-	if video.Thumbnail != nil {
+	if v.img.Image != video.Thumbnail {
 		v.img.Image = video.Thumbnail
 		v.img.Refresh()
 	}
 
+	truncatedName := truncateString(video.Name, maxFileNameLength)
+	v.folderLabel.SetText(truncatePathLeft(video.FolderPath(), maxFolderPathLength))
+
+	switch video.Status {
+	case app.VideoProbing:
+		v.badge.SetResource(theme.ViewRefreshIcon())
+		v.badge.Show()
+		v.label.SetText(fmt.Sprintf("%d. %s\nProbing... (%s)", index+1, truncatedName, video.SizeString()))
+		return
+	case app.VideoOffline:
+		v.badge.SetResource(theme.QuestionIcon())
+		v.badge.Show()
+		v.label.SetText(fmt.Sprintf("%d. %s\nOffline: file not found", index+1, truncatedName))
+		return
+	case app.VideoFailed:
+		v.badge.SetResource(theme.ErrorIcon())
+		v.badge.Show()
+		v.label.SetText(fmt.Sprintf("%d. %s\nError: %s", index+1, truncatedName, truncateString(errorText(video.LoadErr), maxFileNameLength)))
+		return
+	}
+	if video.IsTitle() {
+		v.badge.Hide()
+		v.label.SetText(fmt.Sprintf("%d. %s\n[%s] Title card", index+1, truncatedName, video.DurationString()))
+		return
+	}
+	if video.SourceChanged {
+		v.badge.SetResource(theme.WarningIcon())
+		v.badge.Show()
+	} else {
+		v.badge.Hide()
+	}
+
 	duration := video.DurationString()
+	if video.IsTrimmed() {
+		duration = video.TrimmedDurationString() + " trimmed"
+	}
 	resolution := video.ResolutionString()
-	truncatedName := truncateString(video.Name, maxFileNameLength)
 
 	var info string
 	if duration != "" && resolution != "" {
@@ -164,8 +230,20 @@ func (v *videoItem) update(index int, video *app.Video) {
 	} else {
 		info = fmt.Sprintf("%d. %s\n(%s)", index+1, truncatedName, video.SizeString())
 	}
+	if len(video.Overlays) > 0 {
+		info += fmt.Sprintf(" + %d text overlays", len(video.Overlays))
+	}
+	if video.SourceChanged {
+		info += "\nFile changed since the project was saved"
+	}
 	v.label.SetText(info)
-	v.folderLabel.SetText(truncatePathLeft(video.FolderPath(), maxFolderPathLength))
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return "unknown"
+	}
+	return err.Error()
 }
 
 func (v *videoItem) setSelected(selected bool) {
@@ -183,7 +261,7 @@ func (v *videoItem) setDropTarget(isTarget bool) {
 	if isTarget {
 		v.background.FillColor = color.NRGBA{R: 100, G: 200, B: 100, A: 100}
 	} else {
-		v.setSelected(v.list.state.GetSelected() == v.index)
+		v.setSelected(v.list.state.IsSelected(v.index))
 	}
 	v.background.Refresh()
 }
@@ -207,18 +285,28 @@ func (vl *VideoList) CreateRenderer() fyne.WidgetRenderer {
 
 func (vl *VideoList) Refresh() {
 	videos := vl.state.GetVideos()
-	selected := vl.state.GetSelected()
 
 	for len(vl.items) < len(videos) {
 		item := newVideoItem(vl)
 		vl.items = append(vl.items, item)
 	}
+	for len(vl.markers) < len(videos)-1 {
+		vl.markers = append(vl.markers, newBoundaryMarker(vl))
+	}
+
+	defaults := vl.state.Settings().Export
 
 	vl.container.Objects = nil
 	for i, video := range videos {
+		if i > 0 {
+			marker := vl.markers[i-1]
+			marker.update(i-1, videos[i-1], defaults)
+			vl.container.Add(marker)
+		}
+
 		item := vl.items[i]
 		item.update(i, video)
-		item.setSelected(i == selected)
+		item.setSelected(vl.state.IsSelected(i))
 		vl.container.Add(item)
 	}
 
@@ -226,6 +314,33 @@ func (vl *VideoList) Refresh() {
 	vl.BaseWidget.Refresh()
 }
 
+// RefreshItem updates a single row, for changes that leave the order and
+// length of the list alone, such as a video finishing loading.
+func (vl *VideoList) RefreshItem(index int) {
+	videos := vl.state.GetVideos()
+	if index < 0 || index >= len(videos) || index >= len(vl.items) {
+		vl.Refresh()
+		return
+	}
+
+	item := vl.items[index]
+	item.update(index, videos[index])
+	item.Refresh()
+}
+
+// rowHeight is the distance between the tops of two adjacent rows,
+// including the transition marker between them.
+func (vl *VideoList) rowHeight(item *videoItem) float32 {
+	height := item.Size().Height
+	if height <= 0 {
+		return 80
+	}
+	if len(vl.markers) > 0 {
+		height += vl.markers[0].Size().Height + 2*theme.Padding()
+	}
+	return height
+}
+
 func (vl *VideoList) highlightDropTarget() {
 	for i, item := range vl.items {
 		if i < vl.state.Count() {
@@ -235,10 +350,9 @@ func (vl *VideoList) highlightDropTarget() {
 }
 
 func (vl *VideoList) clearHighlight() {
-	selected := vl.state.GetSelected()
 	for i, item := range vl.items {
 		if i < vl.state.Count() {
-			item.setSelected(i == selected)
+			item.setSelected(vl.state.IsSelected(i))
 		}
 	}
 }